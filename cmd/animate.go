@@ -0,0 +1,192 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package cmd contains all CLI commands
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/arkste/coolctl/driver"
+)
+
+var (
+	// animateFrameRate is the most frames per second sent to the device
+	animateFrameRate float64
+
+	// animateSpeed is how many cycles per second the animation runs, 0 = its default
+	animateSpeed float64
+
+	// animateDuration stops the animation after this time, 0 = never
+	animateDuration time.Duration
+
+	// animateThen is the hardware mode & colors to hand over to when the animation stops
+	animateThen string
+
+	// animateSeed makes random animations repeatable
+	animateSeed int64
+)
+
+// animateCmd represents the animate command
+var animateCmd = &cobra.Command{
+	Use:   "animate",
+	Short: "stream an animation computed on the host to the logo, ring or sync",
+	Long: fmt.Sprintf(`Computes the animation frame by frame & sends every frame as a super-fixed report, until
+interrupted or --duration passed. Then the lighting is handed over to a hardware mode: --then, or the
+lighting coolctl last applied, or off.
+
+Animations: %s`, strings.Join(driver.Animations(), ", ")),
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return usageError("requires a color channel (e.g: logo, ring or sync)")
+		}
+
+		if len(args) < 2 {
+			return usageError(fmt.Sprintf("requires an animation (e.g: %s)", strings.Join(driver.Animations(), ", ")))
+		}
+
+		if animateFrameRate <= 0 || animateFrameRate > driver.MaxFrameRate {
+			return usageError(fmt.Sprintf("the frame rate must be above 0 and at most %d", driver.MaxFrameRate))
+		}
+
+		if animateSpeed < 0 || animateDuration < 0 {
+			return usageError("the speed & duration can't be negative")
+		}
+
+		if cmd.Flags().Changed("then") && len(strings.Fields(animateThen)) == 0 {
+			return usageError("--then requires a color mode (e.g: off or fading FF0000 0000FF)")
+		}
+
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		channel := args[0]
+
+		seed := animateSeed
+		if !cmd.Flags().Changed("seed") {
+			seed = time.Now().UnixNano()
+		}
+
+		animation, err := driver.NewAnimation(args[1], args[2:], animateSpeed, seed)
+		if err != nil {
+			return err
+		}
+
+		// check the hand over before animating, not only once the animation stops
+		if animateThen != "" {
+			if err := handOverThen(driver.NewKrakenDriverWithTransport(driver.NewDryRunTransport(ioutil.Discard)), channel); err != nil {
+				return err
+			}
+		}
+
+		kraken, err := connect(out)
+		if err != nil {
+			return err
+		}
+		defer kraken.Close()
+
+		animator, err := driver.NewAnimator(kraken, channel, animation, animateFrameRate)
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := interruptContext()
+		defer stop()
+
+		stats, err := animator.Run(ctx, animateDuration)
+		if herr := handOver(kraken, channel); err == nil {
+			err = herr
+		}
+		if err != nil {
+			return err
+		}
+
+		return writeAnimationStats(out, stats)
+	},
+}
+
+// handOver leaves `channel` in a hardware mode: --then, the lighting last applied to its LEDs, or off
+func handOver(kraken *driver.KrakenDriver, channel string) error {
+	if animateThen != "" {
+		return handOverThen(kraken, channel)
+	}
+
+	leds := []string{channel}
+	if channel == "sync" {
+		leds = []string{"logo", "ring"}
+	}
+
+	// a color applied to sync is remembered for both logo & ring, but only applied again once
+	var applied []driver.AppliedColor
+	restored := map[string]bool{}
+	for _, led := range leds {
+		var color driver.AppliedColor
+		if kraken.State != nil {
+			color = kraken.State.Color[led]
+		}
+
+		switch {
+		case color.Mode == "":
+			// nothing remembered, turned off before the rest is applied
+			color = driver.AppliedColor{Channel: led, Mode: "off"}
+		case restored[color.Channel]:
+			continue
+		}
+		restored[color.Channel] = true
+		applied = append(applied, color)
+	}
+
+	// in the order applied, so a later logo or ring color isn't overwritten by an earlier sync color
+	sort.SliceStable(applied, func(i, j int) bool {
+		return applied[i].Time.Before(applied[j].Time)
+	})
+
+	for _, color := range applied {
+		speed := color.Speed
+		if speed == "" {
+			speed = "normal"
+		}
+		if err := kraken.SetColor(color.Channel, color.Mode, speed, color.Colors); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// handOverThen sets the mode & colors of --then on `channel`
+func handOverThen(kraken *driver.KrakenDriver, channel string) error {
+	fields := strings.Fields(animateThen)
+
+	return kraken.SetColor(channel, fields[0], "normal", fields[1:])
+}
+
+// writeAnimationStats tells how well the animation kept up with the frame rate
+func writeAnimationStats(w io.Writer, stats driver.AnimationStats) error {
+	rate := 0.0
+	if stats.Duration > 0 {
+		rate = float64(stats.Frames) / stats.Duration.Seconds()
+	}
+
+	_, err := fmt.Fprintf(w, "Animated %d frames in %s at %.1f fps, %d dropped, %s per frame written\n",
+		stats.Frames, stats.Duration.Round(time.Millisecond), rate, stats.Dropped, stats.Latency.Round(time.Microsecond))
+
+	return err
+}
+
+func init() {
+	animateCmd.Flags().Float64Var(&animateFrameRate, "fps", 20, fmt.Sprintf("frames per second at most, up to %d", driver.MaxFrameRate))
+	animateCmd.Flags().Float64Var(&animateSpeed, "speed", 0, "cycles per second, e.g. revolutions of a comet or heart beats, 0 = the animation's default")
+	animateCmd.Flags().DurationVar(&animateDuration, "duration", 0, "stop after this time, 0 = until interrupted")
+	animateCmd.Flags().StringVar(&animateThen, "then", "", "hardware mode & colors to hand over to when stopping (e.g: \"fading FF0000 0000FF\"), the last applied lighting by default")
+	animateCmd.Flags().Int64Var(&animateSeed, "seed", 0, "seed of random animations like fire & rain, random by default")
+	rootCmd.AddCommand(animateCmd)
+}
@@ -5,35 +5,66 @@
 package cmd
 
 import (
-	"errors"
+	"fmt"
 
 	"github.com/spf13/cobra"
 
 	"github.com/arkste/coolctl/driver"
 )
 
+var (
+	// colorSpeed is the animation speed of the color mode
+	colorSpeed string
+
+	// colorFill is the color of LEDs not addressed in a super mode
+	colorFill string
+)
+
 // colorCmd represents the color command
 var colorCmd = &cobra.Command{
 	Use:   "color",
 	Short: "set the color of the logo, ring or sync",
 	Args: func(cmd *cobra.Command, args []string) error {
 		if len(args) < 1 {
-			return errors.New("requires a color channel (e.g: logo, ring or sync)")
+			return usageError("requires a color channel (e.g: logo, ring or sync)")
 		}
 
 		if len(args) < 2 {
-			return errors.New("requires a color mode (e.g: off, fading, etc)")
+			return usageError("requires a color mode (e.g: off, fading, etc)")
 		}
 
 		return nil
 	},
-	Run: func(cmd *cobra.Command, args []string) {
-		kraken := driver.NewKrakenDriver()
-		kraken.Connect()
-		kraken.SetColor(args[0], args[1], args[2:])
+	RunE: func(cmd *cobra.Command, args []string) error {
+		colors := args[2:]
+		if driver.LEDAddressed(colors) || cmd.Flags().Changed("fill") {
+			var err error
+			if colors, err = driver.AddressLEDs(args[1], colors, colorFill); err != nil {
+				return err
+			}
+		}
+
+		// rejected before warning about it, an unknown speed has no effect on any mode
+		if err := driver.ValidSpeed(colorSpeed); err != nil {
+			return err
+		}
+
+		if cmd.Flags().Changed("speed") && !driver.AnimatedColorMode(args[1]) {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: mode %s is not animated, --speed %s has no effect\n", args[1], colorSpeed)
+		}
+
+		kraken, err := connect(cmd.OutOrStdout())
+		if err != nil {
+			return err
+		}
+		defer kraken.Close()
+
+		return kraken.SetColor(args[0], args[1], colorSpeed, colors)
 	},
 }
 
 func init() {
+	colorCmd.Flags().StringVar(&colorSpeed, "speed", "normal", "animation speed (slowest, slower, normal, faster or fastest)")
+	colorCmd.Flags().StringVar(&colorFill, "fill", "", "color of the LEDs not addressed in a super mode (e.g: 202020), off by default")
 	rootCmd.AddCommand(colorCmd)
 }
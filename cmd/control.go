@@ -0,0 +1,211 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package cmd contains all CLI commands
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/arkste/coolctl/driver"
+)
+
+var (
+	// controlSensor is the name of the hwmon temperature sensor the curves follow
+	controlSensor string
+
+	// controlSysfsRoot is where sysfs is mounted
+	controlSysfsRoot string
+
+	// controlInterval is the time between two ticks
+	controlInterval time.Duration
+
+	// controlCount stops after this many ticks, 0 = never
+	controlCount int
+
+	// controlMethod is how duties are set, instant or flat
+	controlMethod string
+
+	// controlCurves are the curves given on the command line, per speed channel
+	controlCurves = map[string]*string{}
+
+	// controlTuning smooths how the duties follow the temperature, per speed channel
+	controlTuning = map[string]*driver.ControlTuning{}
+
+	// controlFile is a YAML, TOML or JSON file with the curves, - reads stdin
+	controlFile string
+
+	// controlListSensors lists the available sensors instead of controlling
+	controlListSensors bool
+)
+
+// controlCmd represents the control command
+var controlCmd = &cobra.Command{
+	Use:   "control",
+	Short: "drive the fan & pump from a CPU or GPU temperature",
+	Long: `Reads a temperature from /sys/class/hwmon every tick, evaluates the curves against it
+and sets the resulting duties, until interrupted.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 {
+			return usageError("control takes no arguments")
+		}
+
+		if controlListSensors {
+			return nil
+		}
+
+		if controlInterval <= 0 {
+			return usageError("the interval must be positive")
+		}
+
+		if controlCount < 0 {
+			return usageError("the count can't be negative")
+		}
+
+		if controlMethod != driver.ControlInstant && controlMethod != driver.ControlFlat {
+			return usageError(fmt.Sprintf("unknown method %q (e.g: instant or flat)", controlMethod))
+		}
+
+		if controlSensor == "" {
+			return usageError("requires a sensor (e.g: --sensor k10temp/Tctl), see --list-sensors")
+		}
+
+		for channel, tuning := range controlTuning {
+			if tuning.Smoothing < 0 || tuning.Hysteresis < 0 || tuning.RampUp < 0 || tuning.RampDown < 0 {
+				return usageError(fmt.Sprintf("the %s smoothing, hysteresis & ramps can't be negative", channel))
+			}
+		}
+
+		if controlFile == "" && *controlCurves["fan"] == "" && *controlCurves["pump"] == "" {
+			return usageError("requires a curve (e.g: --fan \"30 25  50 50  70 100\") or --file")
+		}
+
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if controlListSensors {
+			return listSensors(cmd.OutOrStdout(), controlSysfsRoot)
+		}
+
+		sensor, err := driver.FindHwmonSensor(controlSysfsRoot, controlSensor)
+		if err != nil {
+			return err
+		}
+
+		curves, err := controlProfiles(cmd.InOrStdin())
+		if err != nil {
+			return err
+		}
+
+		kraken, err := connect(cmd.OutOrStdout())
+		if err != nil {
+			return err
+		}
+		defer kraken.Close()
+
+		controller, err := driver.NewController(kraken, sensor, curves)
+		if err != nil {
+			return err
+		}
+		controller.Method = controlMethod
+		for channel, tuning := range controlTuning {
+			controller.Tuning[channel] = *tuning
+		}
+
+		ctx, stop := interruptContext()
+		defer stop()
+
+		out := cmd.OutOrStdout()
+
+		return controller.Run(ctx, controlInterval, controlCount, func(r driver.ControlReading) error {
+			return writeControlLine(out, sensor, r)
+		})
+	},
+}
+
+// controlProfiles returns the curves from --file, overridden by --fan & --pump
+func controlProfiles(stdin io.Reader) (driver.SpeedProfiles, error) {
+	curves := driver.SpeedProfiles{}
+
+	if controlFile != "" {
+		var err error
+		if curves, err = readSpeedProfiles(stdin, controlFile, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	for channel, curve := range controlCurves {
+		if *curve == "" {
+			continue
+		}
+
+		profile, err := driver.ParseSpeedProfile(*curve)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", channel, err)
+		}
+		curves[channel] = profile
+	}
+
+	return curves, nil
+}
+
+// writeControlLine writes a reading as a single human-readable line
+func writeControlLine(w io.Writer, sensor driver.HwmonSensor, r driver.ControlReading) error {
+	line := fmt.Sprintf("%s  %s: %.1f °C", r.Time.Format("15:04:05"), sensor, r.Temperature)
+	for _, channel := range []string{"fan", "pump"} {
+		if duty, ok := r.Duties[channel]; ok {
+			line += fmt.Sprintf("  %s%s duty: %d %%", strings.ToUpper(channel[:1]), channel[1:], duty)
+		}
+	}
+
+	_, err := fmt.Fprintln(w, line)
+
+	return err
+}
+
+// listSensors writes a table of all hwmon temperature sensors below `root`
+func listSensors(w io.Writer, root string) error {
+	sensors, err := driver.HwmonSensors(root)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SENSOR\tDEVICE\tTEMPERATURE")
+	for _, s := range sensors {
+		temp := "-"
+		if t, err := s.ReadTemperature(); err == nil {
+			temp = fmt.Sprintf("%.1f °C", t)
+		}
+		fmt.Fprintf(tw, "%s\t%s/%s\t%s\n", s, s.Device, s.Input, temp)
+	}
+
+	return tw.Flush()
+}
+
+func init() {
+	controlCmd.Flags().StringVar(&controlSensor, "sensor", "", "hwmon temperature sensor to follow (e.g: k10temp/Tctl or coretemp)")
+	controlCmd.Flags().StringVar(&controlSysfsRoot, "sysfs-root", "/sys", "where sysfs is mounted")
+	controlCmd.Flags().DurationVar(&controlInterval, "interval", 2*time.Second, "time between two ticks")
+	controlCmd.Flags().IntVar(&controlCount, "count", 0, "stop after this many ticks")
+	controlCmd.Flags().StringVar(&controlMethod, "method", driver.ControlInstant, "how duties are set (instant or flat)")
+	controlCmd.Flags().StringVarP(&controlFile, "file", "f", "", "read the curves from a YAML, TOML or JSON file, - for stdin")
+	controlCmd.Flags().BoolVar(&controlListSensors, "list-sensors", false, "list the available temperature sensors")
+	for _, channel := range []string{"fan", "pump"} {
+		controlCurves[channel] = controlCmd.Flags().String(channel, "", "curve for the "+channel+" (e.g: 30 25  50 50  70 100)")
+
+		tuning := &driver.ControlTuning{}
+		controlTuning[channel] = tuning
+		controlCmd.Flags().DurationVar(&tuning.Smoothing, channel+"-smoothing", 0, "time constant of the moving average over the temperature for the "+channel)
+		controlCmd.Flags().Float64Var(&tuning.Hysteresis, channel+"-hysteresis", 0, "°C the temperature must move before the "+channel+" duty follows")
+		controlCmd.Flags().Float64Var(&tuning.RampUp, channel+"-ramp-up", 0, "%/s the "+channel+" duty may rise at most, 0 = unlimited")
+		controlCmd.Flags().Float64Var(&tuning.RampDown, channel+"-ramp-down", 0, "%/s the "+channel+" duty may fall at most, 0 = unlimited")
+	}
+	rootCmd.AddCommand(controlCmd)
+}
@@ -0,0 +1,86 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package cmd contains all CLI commands
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/arkste/coolctl/driver"
+)
+
+// listJSON prints the device list as JSON instead of a table
+var listJSON bool
+
+// listEntry describes a single listed device
+type listEntry struct {
+	Index int `json:"index"`
+	driver.DeviceInfo
+	FirmwareVersion string `json:"firmware_version"`
+}
+
+// listCmd represents the list command
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "lists all connected devices",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		kraken := newDriver()
+
+		devices, err := kraken.Devices()
+		if err != nil {
+			return err
+		}
+
+		entries := []listEntry{}
+		for i, device := range devices {
+			entries = append(entries, listEntry{
+				Index:           i,
+				DeviceInfo:      device,
+				FirmwareVersion: readFirmwareVersion(device),
+			})
+		}
+
+		if listJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(entries)
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "INDEX\tBUS\tPORT PATH\tADDRESS\tMANUFACTURER\tPRODUCT\tSERIAL NUMBER\tFIRMWARE")
+		for _, e := range entries {
+			fmt.Fprintf(w, "%d\t%d\t%s\t%d\t%s\t%s\t%s\t%s\n", e.Index, e.Bus, e.PortPath, e.Address, e.Manufacturer, e.Product, e.SerialNumber, e.FirmwareVersion)
+		}
+
+		return w.Flush()
+	},
+}
+
+// readFirmwareVersion connects to `device` & reads its firmware version, or returns an empty string
+func readFirmwareVersion(device driver.DeviceInfo) string {
+	kraken := driver.NewKrakenDriver()
+	if err := kraken.ConnectDevice(device); err != nil {
+		log.Warnf("could not open device on bus %d, address %d: %v", device.Bus, device.Address, err)
+		return ""
+	}
+	defer kraken.Close()
+
+	status, err := kraken.GetStatus()
+	if err != nil {
+		log.Warnf("could not read firmware version of device on bus %d, address %d: %v", device.Bus, device.Address, err)
+		return ""
+	}
+
+	return status.FirmwareVersion.String()
+}
+
+func init() {
+	listCmd.Flags().BoolVar(&listJSON, "json", false, "print the devices as JSON")
+	rootCmd.AddCommand(listCmd)
+}
@@ -0,0 +1,144 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package cmd contains all CLI commands
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/arkste/coolctl/driver"
+)
+
+// outputFormats lists the supported output formats
+var outputFormats = []string{"text", "json", "yaml", "env"}
+
+// firmwareOutput is the machine-readable form of a firmware version
+type firmwareOutput struct {
+	Major int `json:"major" yaml:"major"`
+	Minor int `json:"minor" yaml:"minor"`
+	Patch int `json:"patch" yaml:"patch"`
+}
+
+// statusOutput is the machine-readable form of a status report, field names must stay stable
+type statusOutput struct {
+	LiquidTemperature float64            `json:"liquid_temperature" yaml:"liquid_temperature"`
+	FanSpeed          int                `json:"fan_speed" yaml:"fan_speed"`
+	PumpSpeed         int                `json:"pump_speed" yaml:"pump_speed"`
+	FirmwareVersion   string             `json:"firmware_version" yaml:"firmware_version"`
+	Firmware          firmwareOutput     `json:"firmware" yaml:"firmware"`
+	Curve             driver.CurveConfig `json:"curve" yaml:"curve"`
+	Device            driver.DeviceInfo  `json:"device" yaml:"device"`
+	Time              time.Time          `json:"time" yaml:"time"`
+}
+
+// newStatusOutput converts a status report of the device `kraken` is connected to
+func newStatusOutput(status driver.Status, kraken *driver.KrakenDriver) statusOutput {
+	return statusOutput{
+		LiquidTemperature: status.LiquidTemperature,
+		FanSpeed:          status.FanSpeed,
+		PumpSpeed:         status.PumpSpeed,
+		FirmwareVersion:   status.FirmwareVersion.String(),
+		Firmware: firmwareOutput{
+			Major: status.FirmwareVersion.Major,
+			Minor: status.FirmwareVersion.Minor,
+			Patch: status.FirmwareVersion.Patch,
+		},
+		Curve:  kraken.Curve,
+		Device: kraken.Device,
+		Time:   status.Time,
+	}
+}
+
+// checkOutputFormat rejects unknown output formats
+func checkOutputFormat(format string) error {
+	for _, f := range outputFormats {
+		if f == format {
+			return nil
+		}
+	}
+
+	return usageError(fmt.Sprintf("unknown output format %s (supported: %s)", format, strings.Join(outputFormats, ", ")))
+}
+
+// writeStatus writes `s` to `w` in `format`
+func writeStatus(w io.Writer, format string, s statusOutput) error {
+	switch format {
+	case "json":
+		return json.NewEncoder(w).Encode(s)
+	case "yaml":
+		b, err := yaml.Marshal(s)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append([]byte("---\n"), b...))
+		return err
+	case "env":
+		return writeEnv(w, s)
+	default:
+		_, err := fmt.Fprintf(w, "  Liquid temperature: %.1f °C\n  Fan speed: %d rpm\n  Pump speed: %d rpm\n  Firmware Version: %s\n"+
+			"  Critical temperature: %d °C\n  Curve grid: %d-%d °C in steps of %d °C\n",
+			s.LiquidTemperature, s.FanSpeed, s.PumpSpeed, s.FirmwareVersion,
+			s.Curve.CriticalTemp, s.Curve.GridStart, s.Curve.GridEnd(), s.Curve.GridStep)
+		return err
+	}
+}
+
+// writeEnv writes `s` as KEY=value lines that can be sourced by a shell
+func writeEnv(w io.Writer, s statusOutput) error {
+	vars := []struct {
+		key   string
+		value string
+	}{
+		{"LIQUID_TEMPERATURE", strconv.FormatFloat(s.LiquidTemperature, 'f', -1, 64)},
+		{"FAN_SPEED", strconv.Itoa(s.FanSpeed)},
+		{"PUMP_SPEED", strconv.Itoa(s.PumpSpeed)},
+		{"FIRMWARE_VERSION", s.FirmwareVersion},
+		{"FIRMWARE_MAJOR", strconv.Itoa(s.Firmware.Major)},
+		{"FIRMWARE_MINOR", strconv.Itoa(s.Firmware.Minor)},
+		{"FIRMWARE_PATCH", strconv.Itoa(s.Firmware.Patch)},
+		{"CURVE_CRITICAL_TEMP", strconv.Itoa(s.Curve.CriticalTemp)},
+		{"CURVE_GRID_START", strconv.Itoa(s.Curve.GridStart)},
+		{"CURVE_GRID_STEP", strconv.Itoa(s.Curve.GridStep)},
+		{"DEVICE_BACKEND", s.Device.Backend},
+		{"DEVICE_BUS", strconv.Itoa(s.Device.Bus)},
+		{"DEVICE_PORT_PATH", s.Device.PortPath},
+		{"DEVICE_ADDRESS", strconv.Itoa(s.Device.Address)},
+		{"DEVICE_MANUFACTURER", s.Device.Manufacturer},
+		{"DEVICE_PRODUCT", s.Device.Product},
+		{"DEVICE_SERIAL_NUMBER", s.Device.SerialNumber},
+		{"TIME", s.Time.Format(time.RFC3339Nano)},
+	}
+
+	for _, v := range vars {
+		if _, err := fmt.Fprintf(w, "%s=%s\n", v.key, shellQuote(v.value)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// shellQuote single-quotes `s` unless it only contains characters that are safe in a shell word
+func shellQuote(s string) string {
+	safe := s != ""
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.:/+", r)) {
+			safe = false
+			break
+		}
+	}
+
+	if safe {
+		return s
+	}
+
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
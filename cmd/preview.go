@@ -0,0 +1,81 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package cmd contains all CLI commands
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/arkste/coolctl/driver"
+)
+
+const (
+	chartDutyStep = 5 // % per row
+	chartColWidth = 3 // characters per point of the curve
+
+	chartCurve    = '*'
+	chartInput    = 'o'
+	chartCritical = 'X'
+)
+
+// writeProfileChart plots the effective profile of `profile` on `channel`, marking the input points & the critical point
+func writeProfileChart(w io.Writer, curve driver.CurveConfig, channel string, profile driver.SpeedProfile) error {
+	effective, err := curve.EffectiveProfile(channel, profile)
+	if err != nil {
+		return err
+	}
+
+	first := effective[0][0]
+	step := effective[1][0] - first
+	rows, cols := 100/chartDutyStep+1, len(effective)
+
+	grid := make([][]rune, rows)
+	for i := range grid {
+		grid[i] = []rune(strings.Repeat(" ", cols*chartColWidth))
+	}
+	mark := func(temp, duty int, r rune) {
+		col := int(math.Round(float64(temp-first) / float64(step)))
+		row := int(math.Round(float64(duty) / chartDutyStep))
+		if col < 0 || col >= cols || row < 0 || row >= rows {
+			return
+		}
+		grid[row][col*chartColWidth+1] = r
+	}
+
+	for _, point := range effective {
+		mark(point[0], point[1], chartCurve)
+	}
+	for _, point := range profile {
+		if len(point) == 2 {
+			mark(point[0], point[1], chartInput)
+		}
+	}
+	mark(curve.CriticalTemp, 100, chartCritical)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s profile as run by the device\n\n", strings.Title(channel))
+	for row := rows - 1; row >= 0; row-- {
+		label := "     "
+		if row%2 == 0 {
+			label = fmt.Sprintf("%3d %%", row*chartDutyStep)
+		}
+		fmt.Fprintf(&b, "%s |%s\n", label, strings.TrimRight(string(grid[row]), " "))
+	}
+
+	fmt.Fprintf(&b, "      +%s\n       ", strings.Repeat("-", cols*chartColWidth))
+	for col := 0; col < cols; col += 2 {
+		fmt.Fprintf(&b, "%-*d", 2*chartColWidth, first+col*step)
+	}
+	fmt.Fprintf(&b, "°C\n\n%c effective curve  %c your points  %c critical point, full speed from %d °C\n\n",
+		chartCurve, chartInput, chartCritical, curve.CriticalTemp)
+
+	fmt.Fprintf(&b, "Points: %s\n", formatProfile(effective))
+
+	_, err = io.WriteString(w, b.String())
+
+	return err
+}
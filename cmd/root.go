@@ -5,31 +5,324 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
 
 	"github.com/spf13/cobra"
 
 	"github.com/arkste/coolctl/driver"
 )
 
+const (
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+// exitCodes maps driver errors to distinct process exit codes
+var exitCodes = []struct {
+	err  error
+	code int
+}{
+	{driver.ErrDeviceNotFound, 3},
+	{driver.ErrUnknownChannel, 4},
+	{driver.ErrUnknownMode, 5},
+	{driver.ErrUnsupportedMode, 6},
+	{driver.ErrNotEnoughColors, 7},
+	{driver.ErrInvalidColor, 8},
+	{driver.ErrInvalidProfile, 9},
+	{driver.ErrUnknownBackend, 10},
+	{driver.ErrAmbiguousDevice, 11},
+	{driver.ErrSensorNotFound, 12},
+	{driver.ErrInvalidCurve, 13},
+	{driver.ErrUnknownSpeed, 14},
+}
+
+var (
+	// backend is the name of the backend used to talk to the device
+	backend string
+
+	// selector picks the device to talk to when more than one is connected
+	selector = driver.AnyDevice
+
+	// simulate talks to a simulated device instead of real hardware
+	simulate bool
+
+	// record is the file every report read or written is recorded to
+	record string
+
+	// dryRun prints the reports instead of writing them to the device
+	dryRun bool
+
+	// statePath is the file the applied speeds & colors are remembered in, per device
+	statePath string
+
+	// model picks the curve configuration of this model from curveConfigPath
+	model string
+
+	// curveConfigPath is the file with curve configurations per model
+	curveConfigPath string
+
+	// curve overrides the curve configuration of the model, where its flags are set
+	curve driver.CurveConfig
+)
+
+// usageError marks errors caused by invalid command line arguments
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
-	Use:   "coolctl",
-	Short: "A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72)",
+	Use:           "coolctl",
+	Short:         "A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72)",
+	SilenceErrors: true,
+	SilenceUsage:  true,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute(version string) {
 	rootCmd.Version = version
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	if cmd, err := rootCmd.ExecuteC(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+
+		code := exitCode(err)
+		if code == exitUsage {
+			cmd.Usage()
+		}
+
+		os.Exit(code)
+	}
+}
+
+// newDriver returns a KrakenDriver configured by the global flags
+func newDriver() *driver.KrakenDriver {
+	kraken := driver.NewKrakenDriver()
+	kraken.Backend = backend
+	kraken.Selector = selector
+	if simulate {
+		kraken.Backend = "simulator"
+	}
+
+	return kraken
+}
+
+// connect returns a KrakenDriver connected through the selected backend, recording its traffic if requested;
+// in a dry run no device is opened & the reports are printed to `out` instead
+func connect(out io.Writer) (*driver.KrakenDriver, error) {
+	kraken := newDriver()
+
+	var err error
+	if kraken.Curve, err = curveConfig(); err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		kraken.Transport = driver.NewDryRunTransport(out)
+	} else if err := kraken.Connect(); err != nil {
+		return nil, err
+	}
+
+	if record != "" {
+		trace, err := os.Create(record)
+		if err != nil {
+			kraken.Close()
+			return nil, err
+		}
+		kraken.Transport = driver.NewRecordingTransport(kraken.Transport, trace)
+	}
+
+	// the simulator keeps out of the real state file, unless one is given explicitly
+	keepState := !simulate || rootCmd.PersistentFlags().Changed("state")
+	if statePath != "" && !dryRun && keepState {
+		if kraken.Device.SerialNumber == "" {
+			// the state is kept per serial number
+			fmt.Fprintf(rootCmd.ErrOrStderr(), "Warning: device has no serial number, not remembering state in %s\n", statePath)
+			return kraken, nil
+		}
+
+		state, err := driver.LoadState(statePath)
+		if err != nil {
+			kraken.Close()
+			return nil, err
+		}
+		kraken.State = state.Device(kraken.Device.SerialNumber)
+		kraken.Transport = newStateTransport(kraken.Transport, state, kraken.State)
+	}
+
+	return kraken, nil
+}
+
+// stateTransport saves the state file when the transport is closed, after everything was applied
+type stateTransport struct {
+	driver.Transport
+	state  *driver.State
+	device *driver.DeviceState
+	before []byte // the device state as loaded, in JSON
+}
+
+// newStateTransport wraps `t` to save `state` on close, if the state of `device` changed until then
+func newStateTransport(t driver.Transport, state *driver.State, device *driver.DeviceState) *stateTransport {
+	before, _ := json.Marshal(device)
+
+	return &stateTransport{Transport: t, state: state, device: device, before: before}
+}
+
+// Close closes the wrapped transport & saves the state file, if anything was applied
+func (t *stateTransport) Close() error {
+	err := t.Transport.Close()
+
+	if after, _ := json.Marshal(t.device); !bytes.Equal(t.before, after) {
+		if serr := t.state.Save(statePath); err == nil {
+			err = serr
+		}
+	}
+
+	return err
+}
+
+// defaultStatePath returns the state file in the user's config directory, or an empty string if there is none
+func defaultStatePath() string {
+	return defaultConfigPath("state.json")
+}
+
+// defaultConfigPath returns the path of the file `name` in the coolctl config directory, or an empty string
+func defaultConfigPath(name string) string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "coolctl", name)
+}
+
+// curveConfig returns the curve configuration of the selected model in --curve-config, overridden by the curve
+// flags that are set
+func curveConfig() (driver.CurveConfig, error) {
+	config := driver.DefaultCurve
+	if model == "" && curveConfigPath != "" && rootCmd.PersistentFlags().Changed("curve-config") {
+		return config, usageError(fmt.Sprintf("--curve-config %s requires --model to pick a section (e.g: %s)", curveConfigPath, strings.Join(driver.Models(), ", ")))
+	}
+
+	if model != "" {
+		name := strings.ToLower(model)
+
+		var ok bool
+		if config, ok = driver.CurveConfigs[name]; !ok {
+			return config, usageError(fmt.Sprintf("unknown model %s (supported: %s)", model, strings.Join(driver.Models(), ", ")))
+		}
+
+		configs, err := readCurveConfigs()
+		if err != nil {
+			return config, err
+		}
+		if configured, ok := configs[name]; ok {
+			config = configured
+		}
+	}
+
+	flags := rootCmd.PersistentFlags()
+	if flags.Changed("critical-temp") {
+		config.CriticalTemp = curve.CriticalTemp
+	}
+	if flags.Changed("grid-start") {
+		config.GridStart = curve.GridStart
+	}
+	if flags.Changed("grid-step") {
+		config.GridStep = curve.GridStep
 	}
+
+	return config, config.Validate()
+}
+
+// readCurveConfigs reads --curve-config, the default file may be missing
+func readCurveConfigs() (map[string]driver.CurveConfig, error) {
+	if curveConfigPath == "" {
+		return nil, nil
+	}
+
+	file, err := os.Open(curveConfigPath)
+	if os.IsNotExist(err) && !rootCmd.PersistentFlags().Changed("curve-config") {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	configs, err := driver.ReadCurveConfigs(file, driver.ProfileFormat(curveConfigPath))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", curveConfigPath, err)
+	}
+
+	return configs, nil
+}
+
+// interruptContext returns a context that is canceled on SIGINT or SIGTERM
+func interruptContext() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-signals:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
+
+// exitCode returns the process exit code for `err`
+func exitCode(err error) int {
+	var usage usageError
+	if errors.As(err, &usage) {
+		return exitUsage
+	}
+
+	for _, e := range exitCodes {
+		if errors.Is(err, e.err) {
+			return e.code
+		}
+	}
+
+	return exitFailure
 }
 
 func init() {
 	cobra.OnInitialize()
 	rootCmd.PersistentFlags().IntVarP(&driver.Debug, "debug", "d", 1, "debug level")
+	rootCmd.PersistentFlags().StringVar(&backend, "backend", driver.DefaultBackend, "backend used to talk to the device ("+strings.Join(driver.Backends(), ", ")+")")
+	rootCmd.PersistentFlags().StringVar(&selector.SerialNumber, "serial", "", "select the device by serial number")
+	rootCmd.PersistentFlags().IntVar(&selector.Bus, "bus", 0, "select the device by USB bus")
+	rootCmd.PersistentFlags().IntVar(&selector.Address, "address", 0, "select the device by USB address")
+	rootCmd.PersistentFlags().StringVar(&selector.PortPath, "port-path", "", "select the device by USB port path as shown by list (e.g: 1.4)")
+	rootCmd.PersistentFlags().IntVar(&selector.Index, "index", -1, "select the device by its index in the list command")
+	rootCmd.PersistentFlags().BoolVar(&simulate, "simulate", false, "talk to a simulated device instead of real hardware")
+	rootCmd.PersistentFlags().StringVar(&record, "record", "", "record every report read or written to `FILE` (JSON lines)")
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "print the reports as hex & decoded instead of writing them to the device")
+	rootCmd.PersistentFlags().StringVar(&statePath, "state", defaultStatePath(), "`FILE` the applied speeds & colors are remembered in, empty to forget them (--simulate only uses it if given)")
+	rootCmd.PersistentFlags().StringVar(&model, "model", "", "device model whose section of --curve-config applies ("+strings.Join(driver.Models(), ", ")+")")
+	rootCmd.PersistentFlags().StringVar(&curveConfigPath, "curve-config", defaultConfigPath("curves.yaml"), "YAML, TOML or JSON `FILE` with curve configurations per model")
+	rootCmd.PersistentFlags().IntVar(&curve.CriticalTemp, "critical-temp", driver.DefaultCurve.CriticalTemp, "°C from which fan & pump always run at full speed")
+	rootCmd.PersistentFlags().IntVar(&curve.GridStart, "grid-start", driver.DefaultCurve.GridStart, "°C of the first of the 21 curve points")
+	rootCmd.PersistentFlags().IntVar(&curve.GridStep, "grid-step", driver.DefaultCurve.GridStep, "°C between two curve points")
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return usageError(err.Error())
+	})
 }
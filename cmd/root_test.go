@@ -0,0 +1,571 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package cmd contains all CLI commands
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/arkste/coolctl/driver"
+)
+
+// testStatePath is the state file of the tests, instead of the one in the user's config directory
+var testStatePath string
+
+func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "coolctl-cmd")
+	if err != nil {
+		panic(err)
+	}
+	testStatePath = filepath.Join(dir, "state.json")
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// execute runs coolctl with `args` against the simulator & returns its output
+func execute(t *testing.T, args ...string) (string, error) {
+	resetFlags(rootCmd)
+
+	var out bytes.Buffer
+	rootCmd.SetOutput(&out)
+	rootCmd.SetArgs(append([]string{"--simulate", "--state", testStatePath, "--curve-config", ""}, args...))
+	defer rootCmd.SetOutput(nil)
+
+	_, err := rootCmd.ExecuteC()
+
+	return out.String(), err
+}
+
+// resetFlags restores the defaults of all flags of `cmd` & its subcommands
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		f.Value.Set(f.DefValue)
+		f.Changed = false
+	}
+	cmd.PersistentFlags().VisitAll(reset)
+	cmd.Flags().VisitAll(reset)
+
+	for _, c := range cmd.Commands() {
+		resetFlags(c)
+	}
+}
+
+func TestStatusCommand(t *testing.T) {
+	out, err := execute(t, "status")
+
+	assert.Nil(t, err)
+	assert.Contains(t, out, "Liquid temperature: 30.0 °C")
+	assert.Contains(t, out, "Firmware Version: 6.0.2")
+	assert.Contains(t, out, "Critical temperature: 60 °C")
+	assert.Contains(t, out, "Curve grid: 20-60 °C in steps of 2 °C")
+}
+
+func TestStatusCommandCurveConfigFile(t *testing.T) {
+	out, err := execute(t, "--model", "x62", "--curve-config", "testdata/curves.toml", "status")
+
+	assert.Nil(t, err)
+	assert.Contains(t, out, "Critical temperature: 55 °C")
+	assert.Contains(t, out, "Curve grid: 15-55 °C in steps of 2 °C")
+
+	// only the section of the model applies, the flags override it
+	out, err = execute(t, "--model", "x42", "--curve-config", "testdata/curves.toml", "--critical-temp", "40", "--grid-step", "1", "status")
+
+	assert.Nil(t, err)
+	assert.Contains(t, out, "Critical temperature: 40 °C")
+	assert.Contains(t, out, "Curve grid: 20-40 °C in steps of 1 °C")
+}
+
+func TestStatusCommandCurveConfig(t *testing.T) {
+	out, err := execute(t, "--model", "X62", "--critical-temp", "50", "--grid-start", "30", "--grid-step", "1", "status", "-o", "json")
+	require.Nil(t, err)
+
+	var status map[string]interface{}
+	require.Nil(t, json.Unmarshal([]byte(out), &status))
+	assert.Equal(t, map[string]interface{}{"critical_temp": 50.0, "grid_start": 30.0, "grid_step": 1.0}, status["curve"])
+}
+
+func TestStatusCommandJSON(t *testing.T) {
+	out, err := execute(t, "status", "--output", "json")
+	require.Nil(t, err)
+
+	var status map[string]interface{}
+	require.Nil(t, json.Unmarshal([]byte(out), &status))
+	assert.Equal(t, 30.0, status["liquid_temperature"])
+	assert.Equal(t, "6.0.2", status["firmware_version"])
+	assert.Equal(t, "SIMULATED", status["device"].(map[string]interface{})["serial_number"])
+}
+
+func TestStatusCommandEnv(t *testing.T) {
+	out, err := execute(t, "status", "-o", "env")
+
+	assert.Nil(t, err)
+	assert.Contains(t, out, "FIRMWARE_MAJOR=6\n")
+	assert.Contains(t, out, "DEVICE_PRODUCT='Simulated Kraken X'\n")
+}
+
+func TestStatusCommandWatch(t *testing.T) {
+	out, err := execute(t, "status", "--watch", "--count", "3", "--interval", "1ms", "-o", "json")
+
+	assert.Nil(t, err)
+	assert.Len(t, strings.Split(strings.TrimSpace(out), "\n"), 3)
+}
+
+func TestStatusCommandInvalidOutput(t *testing.T) {
+	_, err := execute(t, "status", "--output", "xml")
+
+	assert.Equal(t, exitUsage, exitCode(err))
+}
+
+func TestListCommand(t *testing.T) {
+	out, err := execute(t, "list")
+
+	assert.Nil(t, err)
+	assert.Contains(t, out, "SERIAL NUMBER")
+	assert.Contains(t, out, "Simulated Kraken X")
+	assert.Contains(t, out, "6.0.2")
+}
+
+func TestListCommandJSON(t *testing.T) {
+	out, err := execute(t, "list", "--json")
+	require.Nil(t, err)
+
+	var entries []listEntry
+	require.Nil(t, json.Unmarshal([]byte(out), &entries))
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "SIMULATED", entries[0].SerialNumber)
+	assert.Equal(t, "6.0.2", entries[0].FirmwareVersion)
+}
+
+func TestColorCommand(t *testing.T) {
+	_, err := execute(t, "color", "ring", "fading", "FF0000", "00FF00")
+
+	assert.Nil(t, err)
+}
+
+func TestColorCommandSpeed(t *testing.T) {
+	out, err := execute(t, "--dry-run", "color", "ring", "fading", "--speed", "faster", "FF0000", "00FF00")
+
+	assert.Nil(t, err)
+	assert.Contains(t, out, "ring, mode fading, step 1, speed faster, color #FF0000")
+
+	out, err = execute(t, "--dry-run", "color", "logo", "fixed", "--speed", "faster", "FF0000")
+
+	assert.Nil(t, err)
+	assert.Contains(t, out, "Warning: mode fixed is not animated, --speed faster has no effect\n")
+}
+
+func TestColorCommandColors(t *testing.T) {
+	out, err := execute(t, "--dry-run", "color", "sync", "super-fixed", "rebeccapurple", "#F80", "rgb(0,0,255)", "hsl(120,100%,50%)", "2700K")
+
+	assert.Nil(t, err)
+	assert.Contains(t, out, "logo #663399, ring #FF8800 #0000FF #00FF00 #FFA757 #000000")
+
+	_, err = execute(t, "--dry-run", "color", "ring", "fixed", "FF")
+
+	assert.Equal(t, 8, exitCode(err))
+	assert.EqualError(t, err, `invalid color "FF": hex colors have 3 or 6 digits, not 2`)
+}
+
+func TestColorCommandLEDs(t *testing.T) {
+	out, err := execute(t, "--dry-run", "color", "sync", "super-fixed", "logo=red", "ring[0-3]=00FF00", "ring[4,6]=0000FF", "--fill", "101010")
+
+	assert.Nil(t, err)
+	assert.Contains(t, out, "logo #FF0000, ring #00FF00 #00FF00 #00FF00 #00FF00 #0000FF #101010 #0000FF #101010")
+
+	_, err = execute(t, "--dry-run", "color", "ring", "super-wave", "ring[8]=red")
+
+	assert.Equal(t, 8, exitCode(err))
+	assert.EqualError(t, err, `invalid color "ring[8]=red": ring LED 8 does not exist, the ring has LEDs 0-7`)
+}
+
+func TestColorCommandGenerators(t *testing.T) {
+	out, err := execute(t, "--dry-run", "color", "ring", "super-fixed", "gradient:hsv", "red", "blue")
+
+	assert.Nil(t, err)
+	assert.Contains(t, out, "ring, mode super-fixed, step 1, speed normal, colors #FF0000 #FF0049 #FF0092 #FF00DB #DB00FF #9200FF #4900FF #0000FF")
+
+	_, err = execute(t, "--dry-run", "color", "ring", "fading", "gradient", "red")
+
+	assert.Equal(t, 7, exitCode(err))
+}
+
+func TestColorCommandUnknownSpeed(t *testing.T) {
+	out, err := execute(t, "--dry-run", "color", "ring", "off", "--speed", "bogus")
+
+	assert.True(t, errors.Is(err, driver.ErrUnknownSpeed))
+	assert.Equal(t, 14, exitCode(err))
+	assert.NotContains(t, out, "Warning")
+	assert.NotContains(t, out, "write:")
+}
+
+func TestAnimateCommand(t *testing.T) {
+	out, err := execute(t, "--dry-run", "animate", "ring", "comet", "red", "--duration", "100ms", "--then", "fading red blue")
+
+	require.Nil(t, err)
+	assert.Contains(t, out, "ring, mode super-fixed, step 1, speed normal, colors #FF0000 #000000")
+	assert.Contains(t, out, "ring, mode fading, step 1, speed normal, color #FF0000\n")
+	assert.Contains(t, out, "ring, mode fading, step 2, speed normal, color #0000FF\n")
+	assert.Regexp(t, `Animated \d+ frames in .* at .* fps, \d+ dropped, .* per frame written\n$`, out)
+}
+
+func TestAnimateCommandHandOver(t *testing.T) {
+	os.Remove(testStatePath)
+
+	_, err := execute(t, "color", "sync", "fading", "--speed", "faster", "red", "blue")
+	require.Nil(t, err)
+	_, err = execute(t, "--record", filepath.Join(filepath.Dir(testStatePath), "animate.jsonl"), "animate", "sync", "heartbeat", "--duration", "50ms")
+	require.Nil(t, err)
+
+	// the fading applied before is applied again, once for sync
+	b, err := ioutil.ReadFile(filepath.Join(filepath.Dir(testStatePath), "animate.jsonl"))
+	require.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	require.True(t, len(lines) > 2)
+	assert.Contains(t, lines[len(lines)-2], `"data":"024c0001`)
+	assert.Contains(t, lines[len(lines)-1], `"data":"024c0001`)
+	assert.NotContains(t, lines[len(lines)-3], `"data":"024c0001`)
+
+	out, err := execute(t, "show")
+
+	assert.Nil(t, err)
+	assert.Contains(t, out, "  Logo: fading red blue at faster speed (via sync), applied ")
+}
+
+func TestAnimateCommandHandOverOrder(t *testing.T) {
+	os.Remove(testStatePath)
+
+	_, err := execute(t, "color", "sync", "fixed", "red")
+	require.Nil(t, err)
+	_, err = execute(t, "color", "logo", "fixed", "blue")
+	require.Nil(t, err)
+	_, err = execute(t, "--record", filepath.Join(filepath.Dir(testStatePath), "animate.jsonl"), "animate", "sync", "heartbeat", "--duration", "20ms")
+	require.Nil(t, err)
+
+	// sync first, then the logo color applied after it
+	b, err := ioutil.ReadFile(filepath.Join(filepath.Dir(testStatePath), "animate.jsonl"))
+	require.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	require.True(t, len(lines) > 2)
+	assert.Contains(t, lines[len(lines)-2], `"data":"024c00000200ff00`)
+	assert.Contains(t, lines[len(lines)-1], `"data":"024c0100020000ff`)
+
+	out, err := execute(t, "show")
+
+	assert.Nil(t, err)
+	assert.Contains(t, out, "  Logo: fixed blue, applied ")
+	assert.Contains(t, out, "  Ring: fixed red (via sync), applied ")
+}
+
+func TestSpeedCommand(t *testing.T) {
+	_, err := execute(t, "speed", "fan", "20", "25", "35", "25", "50", "55", "60", "100")
+
+	assert.Nil(t, err)
+}
+
+func TestSpeedCommandSyntaxes(t *testing.T) {
+	out, err := execute(t, "speed", "--preview", "fan", "20°C:25%,", "95F:25%,", "50C:55%,", "60C:100%")
+
+	require.Nil(t, err)
+	assert.Contains(t, out, "Points: 20 25  22 25  24 25  26 25  28 25  30 25  32 25  34 25  36 27")
+}
+
+func TestSpeedCommandWarnings(t *testing.T) {
+	out, err := execute(t, "speed", "pump", "20", "40", "35", "60", "60", "100")
+
+	assert.Nil(t, err)
+	assert.Contains(t, out, "Warning: pump profile point 20 40: duty below the pump minimum of 50 %, it runs at 50 %\n")
+
+	out, err = execute(t, "speed", "--lenient", "pump", "20", "40", "35", "60", "60", "100")
+
+	assert.Nil(t, err)
+	assert.Empty(t, out)
+}
+
+func TestSpeedCommandStrict(t *testing.T) {
+	_, err := execute(t, "speed", "--strict", "fan", "20", "25", "40", "60", "50", "40", "60", "100")
+
+	assert.Equal(t, 9, exitCode(err))
+	assert.Contains(t, err.Error(), "point 50 40: duty decreases from 60 % at 40 °C")
+}
+
+func TestSpeedCommandPreview(t *testing.T) {
+	// previews never connect, so no device has to match
+	out, err := execute(t, "--serial", "nope", "speed", "--preview", "--lenient", "pump", "30", "40", "45", "70")
+
+	require.Nil(t, err)
+	lines := strings.Split(out, "\n")
+	assert.Equal(t, "Pump profile as run by the device", lines[0])
+	assert.Equal(t, "100 % |                                                             X", lines[2])
+	assert.Equal(t, " 50 % | *  *  *  *  *  *  *  *  *", lines[12])
+	assert.Equal(t, " 40 % |                o", lines[14])
+	assert.Contains(t, out, "Points: 20 50  22 50  24 50")
+}
+
+func TestStateWithoutSerialNumber(t *testing.T) {
+	os.Remove(testStatePath)
+	serial := driver.SimulatorDevice.SerialNumber
+	driver.SimulatorDevice.SerialNumber = ""
+	defer func() { driver.SimulatorDevice.SerialNumber = serial }()
+
+	out, err := execute(t, "speed", "fan", "--fixed", "40")
+
+	require.Nil(t, err)
+	assert.Equal(t, "Warning: device has no serial number, not remembering state in "+testStatePath+"\n"+
+		"Set fan to 40 % as a flat profile (firmware 6.0.2 supports cooling profiles)\n", out)
+	_, err = os.Stat(testStatePath)
+	assert.True(t, os.IsNotExist(err))
+
+	// nothing to warn about without a state file
+	out, err = execute(t, "--state", "", "speed", "fan", "--fixed", "40")
+
+	require.Nil(t, err)
+	assert.NotContains(t, out, "Warning")
+}
+
+func TestSimulatorSkipsDefaultState(t *testing.T) {
+	// stand in for the default state file, without touching the real one
+	flag := rootCmd.PersistentFlags().Lookup("state")
+	defaultPath := flag.DefValue
+	flag.DefValue = filepath.Join(filepath.Dir(testStatePath), "default-state.json")
+	defer func() {
+		flag.DefValue = defaultPath
+		resetFlags(rootCmd)
+	}()
+	resetFlags(rootCmd)
+
+	var out bytes.Buffer
+	rootCmd.SetOutput(&out)
+	rootCmd.SetArgs([]string{"--simulate", "--curve-config", "", "speed", "fan", "--fixed", "40"})
+	defer rootCmd.SetOutput(nil)
+
+	_, err := rootCmd.ExecuteC()
+
+	require.Nil(t, err)
+	_, err = os.Stat(flag.DefValue)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestSpeedCommandFixed(t *testing.T) {
+	out, err := execute(t, "speed", "fan", "--fixed", "40")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Set fan to 40 % as a flat profile (firmware 6.0.2 supports cooling profiles)\n", out)
+
+	out, err = execute(t, "speed", "pump", "--fixed", "70", "--instant")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Set pump to 70 % instantly (forced by --instant), the device does not keep it\n", out)
+}
+
+func TestSpeedCommandFixedClamped(t *testing.T) {
+	os.Remove(testStatePath)
+
+	out, err := execute(t, "speed", "fan", "--fixed", "10")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Warning: fan duty 10 % below the fan minimum of 25 %, it runs at 25 %\n"+
+		"Set fan to 25 % as a flat profile (firmware 6.0.2 supports cooling profiles)\n", out)
+
+	out, err = execute(t, "show")
+
+	assert.Nil(t, err)
+	assert.Contains(t, out, "  Fan: fixed at 25 % (flat profile), applied ")
+
+	out, err = execute(t, "speed", "pump", "--fixed", "10", "--instant", "--lenient")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Set pump to 50 % instantly (forced by --instant), the device does not keep it\n", out)
+}
+
+func TestSpeedCommandFixedDryRun(t *testing.T) {
+	out, err := execute(t, "--dry-run", "speed", "pump", "--fixed", "70", "--instant")
+
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(out, "write: 02 4d 40 00 46\n       pump duty: 70% (instant)\n"), out)
+}
+
+func TestSpeedCommandFile(t *testing.T) {
+	_, err := execute(t, "speed", "--file", "testdata/silent.yaml")
+	assert.Nil(t, err)
+
+	_, err = execute(t, "speed", "pump", "--file", "testdata/silent.yaml")
+	assert.Nil(t, err)
+}
+
+func TestSpeedCommandStdin(t *testing.T) {
+	rootCmd.SetIn(strings.NewReader(`{"fan": [[20, 25], [60, 100]]}`))
+	defer rootCmd.SetIn(nil)
+
+	_, err := execute(t, "speed", "--file", "-")
+
+	assert.Nil(t, err)
+}
+
+func TestControlCommand(t *testing.T) {
+	out, err := execute(t, "control", "--sysfs-root", "testdata/sys", "--sensor", "k10temp/Tctl",
+		"--fan", "30 25  50 50  70 100", "--count", "2", "--interval", "1ms")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, strings.Count(out, "k10temp/Tctl: 55.2 °C  Fan duty: 63 %\n"), out)
+}
+
+func TestControlCommandTuning(t *testing.T) {
+	out, err := execute(t, "control", "--sysfs-root", "testdata/sys", "--sensor", "k10temp/Tctl",
+		"--fan", "30 25  50 50  70 100", "--fan-smoothing", "10s", "--fan-hysteresis", "1.5",
+		"--fan-ramp-up", "5", "--fan-ramp-down", "2", "--count", "1")
+
+	assert.Nil(t, err)
+	assert.Contains(t, out, "Fan duty: 63 %")
+}
+
+func TestControlCommandListSensors(t *testing.T) {
+	out, err := execute(t, "control", "--sysfs-root", "testdata/sys", "--list-sensors")
+
+	assert.Nil(t, err)
+	assert.Contains(t, out, "k10temp/Tccd1")
+	assert.Contains(t, out, "hwmon1/temp3")
+	assert.Contains(t, out, "48.0 °C")
+}
+
+func TestDryRunFlag(t *testing.T) {
+	// dry runs never connect, so no device has to match
+	out, err := execute(t, "--serial", "nope", "--dry-run", "color", "logo", "fixed", "FF0000")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "write: 02 4c 01 00 02 00 ff 00 ff 00 00 ff 00 00 ff 00 00 ff 00 00 ff 00 00 ff 00 00 ff 00 00 ff\n"+
+		"       logo, mode fixed, step 1, speed normal, color #FF0000\n", out)
+}
+
+func TestRecordFlag(t *testing.T) {
+	dir, err := ioutil.TempDir("", "coolctl")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	trace := filepath.Join(dir, "trace.jsonl")
+
+	_, err = execute(t, "--record", trace, "color", "logo", "fixed", "FF0000")
+	require.Nil(t, err)
+
+	file, err := os.Open(trace)
+	require.Nil(t, err)
+	defer file.Close()
+
+	replay, err := driver.NewReplayTransport(file)
+	require.Nil(t, err)
+	require.Len(t, replay.Entries, 1)
+	assert.Equal(t, driver.TraceWrite, replay.Entries[0].Direction)
+	assert.True(t, strings.HasPrefix(replay.Entries[0].Data, "024c01000200ff00"))
+}
+
+func TestShowCommand(t *testing.T) {
+	os.Remove(testStatePath)
+
+	_, err := execute(t, "speed", "fan", "20", "25", "35", "25", "50", "55", "60", "100")
+	require.Nil(t, err)
+	_, err = execute(t, "speed", "pump", "--fixed", "70")
+	require.Nil(t, err)
+	_, err = execute(t, "color", "sync", "fading", "--speed", "slower", "FF0000", "00FF00")
+	require.Nil(t, err)
+
+	out, err := execute(t, "show")
+
+	assert.Nil(t, err)
+	assert.Contains(t, out, "Device SIMULATED\n")
+	assert.Contains(t, out, "  Fan: profile, applied ")
+	assert.Contains(t, out, "    Profile: 20 25  35 25  50 55  60 100\n")
+	assert.Contains(t, out, "    Points: 20 25  22 25  24 25")
+	assert.Contains(t, out, "  Pump: fixed at 70 % (flat profile), applied ")
+	assert.Contains(t, out, "  Logo: fading FF0000 00FF00 at slower speed (via sync), applied ")
+	assert.Contains(t, out, "  Ring: fading FF0000 00FF00 at slower speed (via sync), applied ")
+
+	out, err = execute(t, "--serial", "SIMULATED", "show", "--output", "json")
+
+	assert.Nil(t, err)
+	var devices map[string]driver.DeviceState
+	require.Nil(t, json.Unmarshal([]byte(out), &devices))
+	assert.Equal(t, driver.SpeedFixedMethod, devices["SIMULATED"].Speed["pump"].Method)
+	assert.Equal(t, 70, devices["SIMULATED"].Speed["pump"].Duty)
+}
+
+func TestShowCommandDryRun(t *testing.T) {
+	os.Remove(testStatePath)
+
+	_, err := execute(t, "--dry-run", "speed", "fan", "20", "25")
+	require.Nil(t, err)
+
+	out, err := execute(t, "show")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Nothing applied yet\n", out)
+}
+
+var exitCodeTests = []struct {
+	args []string
+	code int
+}{
+	{[]string{"color"}, exitUsage},
+	{[]string{"speed", "fan"}, exitUsage},
+	{[]string{"status", "--bogus"}, exitUsage},
+	{[]string{"--serial", "nope", "status"}, 3},
+	{[]string{"color", "case", "fixed", "FF0000"}, 4},
+	{[]string{"color", "ring", "disco"}, 5},
+	{[]string{"color", "logo", "loading", "FF0000"}, 6},
+	{[]string{"color", "ring", "fading", "FF0000"}, 7},
+	{[]string{"color", "ring", "fixed", "foobar"}, 8},
+	{[]string{"speed", "fan", "20", "fast"}, 9},
+	{[]string{"speed", "fan", "20", "25", "60"}, 9},
+	{[]string{"speed", "fan", "20:25:60"}, 9},
+	{[]string{"speed", "--strict", "--lenient", "fan", "20", "25"}, exitUsage},
+	{[]string{"speed", "fan", "--fixed", "140"}, exitUsage},
+	{[]string{"speed", "fan", "--fixed", "10", "--strict"}, 9},
+	{[]string{"speed", "fan", "20", "25", "--fixed", "40"}, exitUsage},
+	{[]string{"speed", "fan", "20", "25", "--instant"}, exitUsage},
+	{[]string{"speed", "case", "--fixed", "40"}, 4},
+	{[]string{"--model", "x99", "status"}, exitUsage},
+	{[]string{"--curve-config", "testdata/curves.toml", "status"}, exitUsage},
+	{[]string{"--model", "x62", "--curve-config", "testdata/missing.yaml", "status"}, 1},
+	{[]string{"--model", "x62", "--curve-config", "testdata/silent.yaml", "status"}, 13},
+	{[]string{"--critical-temp", "70", "status"}, 13},
+	{[]string{"--grid-step", "5", "speed", "--preview", "fan", "20", "25"}, 13},
+	{[]string{"speed", "--file", "testdata/silent.yaml", "--format", "ini"}, exitUsage},
+	{[]string{"speed", "fan", "20", "--file", "testdata/silent.yaml"}, exitUsage},
+	{[]string{"control", "--fan", "30 25"}, exitUsage},
+	{[]string{"control", "--sensor", "k10temp", "--fan", "30 25", "--method", "turbo"}, exitUsage},
+	{[]string{"control", "--sensor", "k10temp", "--fan", "30 25", "--pump-ramp-down", "-1"}, exitUsage},
+	{[]string{"control", "--sysfs-root", "testdata/sys", "--sensor", "coretemp", "--fan", "30 25"}, 12},
+	{[]string{"control", "--sysfs-root", "testdata/sys", "--sensor", "k10temp", "--fan", "30"}, 9},
+	{[]string{"color", "ring", "fading", "--speed", "ludicrous", "FF0000", "00FF00"}, 14},
+	{[]string{"animate", "ring"}, exitUsage},
+	{[]string{"animate", "ring", "disco"}, 5},
+	{[]string{"animate", "ring", "comet", "--fps", "0"}, exitUsage},
+	{[]string{"animate", "ring", "comet", "--fps", "100"}, exitUsage},
+	{[]string{"animate", "ring", "comet", "--then", " "}, exitUsage},
+	{[]string{"animate", "ring", "comet", "--then", "disco"}, 5},
+	{[]string{"animate", "case", "comet", "--duration", "10ms"}, 4},
+	{[]string{"show", "--output", "env"}, exitUsage},
+	{[]string{"--serial", "UNKNOWN", "show"}, 3},
+}
+
+func TestExitCodes(t *testing.T) {
+	for _, tt := range exitCodeTests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			_, err := execute(t, tt.args...)
+
+			assert.Equal(t, tt.code, exitCode(err))
+		})
+	}
+}
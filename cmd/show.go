@@ -0,0 +1,159 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package cmd contains all CLI commands
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"github.com/arkste/coolctl/driver"
+)
+
+// showOutputFormat is the format the remembered state is printed in
+var showOutputFormat string
+
+// showCmd represents the show command
+var showCmd = &cobra.Command{
+	Use:   "show",
+	Short: "show the speeds & colors last applied",
+	Long: `Prints the speed profiles & lighting coolctl last applied to each channel, as the device
+can't report them back. Only the device selected by --serial is shown, otherwise all devices.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 {
+			return usageError("show takes no arguments")
+		}
+
+		if showOutputFormat != "text" && showOutputFormat != "json" && showOutputFormat != "yaml" {
+			return usageError(fmt.Sprintf("unknown output format %s (supported: text, json, yaml)", showOutputFormat))
+		}
+
+		if statePath == "" {
+			return usageError("requires a state file (e.g: --state ~/.config/coolctl/state.json)")
+		}
+
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		state, err := driver.LoadState(statePath)
+		if err != nil {
+			return err
+		}
+
+		if selector.SerialNumber != "" {
+			device, ok := state.Devices[selector.SerialNumber]
+			if !ok {
+				return fmt.Errorf("%w: nothing applied to %s yet", driver.ErrDeviceNotFound, selector.SerialNumber)
+			}
+			state.Devices = map[string]*driver.DeviceState{selector.SerialNumber: device}
+		}
+
+		return writeState(cmd.OutOrStdout(), showOutputFormat, state)
+	},
+}
+
+// writeState writes `state` to `w` in `format`
+func writeState(w io.Writer, format string, state *driver.State) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(state.Devices)
+	case "yaml":
+		b, err := yaml.Marshal(state.Devices)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(b)
+		return err
+	}
+
+	if len(state.Devices) == 0 {
+		_, err := fmt.Fprintln(w, "Nothing applied yet")
+		return err
+	}
+
+	var b strings.Builder
+	for i, serial := range state.Serials() {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "Device %s\n", serial)
+
+		device := state.Devices[serial]
+		for _, channel := range []string{"fan", "pump"} {
+			if speed, ok := device.Speed[channel]; ok {
+				writeAppliedSpeed(&b, channel, speed)
+			}
+		}
+		for _, channel := range []string{"logo", "ring"} {
+			if color, ok := device.Color[channel]; ok {
+				writeAppliedColor(&b, channel, color)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+// writeAppliedSpeed describes the speed last applied to `channel`, with the exact points the device runs
+func writeAppliedSpeed(b *strings.Builder, channel string, speed driver.AppliedSpeed) {
+	what := "profile"
+	switch speed.Method {
+	case driver.SpeedFixedMethod:
+		what = fmt.Sprintf("fixed at %d %% (flat profile)", speed.Duty)
+	case driver.SpeedInstantMethod:
+		what = fmt.Sprintf("fixed at %d %% (instant, the device does not keep it)", speed.Duty)
+	}
+	fmt.Fprintf(b, "  %s: %s, applied %s\n", strings.Title(channel), what, speed.Time.Format(time.RFC3339))
+
+	if len(speed.Profile) > 0 {
+		fmt.Fprintf(b, "    Profile: %s\n", formatProfile(speed.Profile))
+	}
+	if len(speed.Points) > 0 {
+		fmt.Fprintf(b, "    Points: %s\n", formatProfile(speed.Points))
+	}
+}
+
+// writeAppliedColor describes the lighting last applied to `channel`
+func writeAppliedColor(b *strings.Builder, channel string, color driver.AppliedColor) {
+	what := color.Mode
+	if len(color.Colors) > 0 {
+		what += " " + strings.Join(color.Colors, " ")
+	}
+	if color.Speed != "" && driver.AnimatedColorMode(color.Mode) {
+		what += " at " + color.Speed + " speed"
+	}
+	if color.Channel != channel {
+		what += " (via " + color.Channel + ")"
+	}
+	fmt.Fprintf(b, "  %s: %s, applied %s\n", strings.Title(channel), what, color.Time.Format(time.RFC3339))
+}
+
+// formatProfile formats `profile` the way it is given on the command line, e.g. 20 25  35 25
+func formatProfile(profile driver.SpeedProfile) string {
+	var points []string
+	for _, point := range profile {
+		var values []string
+		for _, v := range point {
+			values = append(values, fmt.Sprint(v))
+		}
+		points = append(points, strings.Join(values, " "))
+	}
+
+	return strings.Join(points, "  ")
+}
+
+func init() {
+	showCmd.Flags().StringVarP(&showOutputFormat, "output", "o", "text", "output format (text, json or yaml)")
+	rootCmd.AddCommand(showCmd)
+}
@@ -5,7 +5,10 @@
 package cmd
 
 import (
-	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -13,38 +16,247 @@ import (
 	"github.com/arkste/coolctl/driver"
 )
 
+var (
+	// speedFile is a YAML, TOML or JSON file with the profiles to set, - reads stdin
+	speedFile string
+
+	// speedFormat overrides the format of the profile file
+	speedFormat string
+
+	// speedStrict rejects profiles & fixed duties that would be clamped or rewritten
+	speedStrict bool
+
+	// speedLenient sets such profiles & duties without warning
+	speedLenient bool
+
+	// speedPreview plots the profiles as run by the device instead of setting them
+	speedPreview bool
+
+	// speedFixed is a fixed duty to set instead of a profile
+	speedFixed int
+
+	// speedInstant forces setting the fixed duty instantly, without persistence
+	speedInstant bool
+)
+
 // speedCmd represents the speed command
 var speedCmd = &cobra.Command{
 	Use:   "speed",
 	Short: "set the speed of the pump or fan",
 	Args: func(cmd *cobra.Command, args []string) error {
+		if speedStrict && speedLenient {
+			return usageError("--strict and --lenient can't be combined")
+		}
+
+		if cmd.Flags().Changed("fixed") {
+			if len(args) != 1 || speedFile != "" || speedPreview {
+				return usageError("--fixed requires a speed channel & can't be combined with a profile, --file or --preview")
+			}
+
+			if speedFixed < 0 || speedFixed > 100 {
+				return usageError("the fixed duty must be between 0 and 100")
+			}
+
+			return nil
+		} else if speedInstant {
+			return usageError("--instant requires --fixed")
+		}
+
+		if speedFile != "" {
+			if len(args) > 1 {
+				return usageError("a profile file can't be combined with a speed profile")
+			}
+
+			switch speedFormat {
+			case "", "yaml", "toml", "json":
+			default:
+				return usageError(fmt.Sprintf("unknown file format %q (e.g: yaml, toml or json)", speedFormat))
+			}
+
+			return nil
+		}
+
 		if len(args) < 1 {
-			return errors.New("requires a speed channel (e.g: pump or fan)")
+			return usageError("requires a speed channel (e.g: pump or fan)")
 		}
 
 		if len(args) < 2 {
-			return errors.New("requires a speed profile (e.g: 20 25  35 25  50 55  60 100)")
+			return usageError("requires a speed profile (e.g: 20 25  35 25  50 55  60 100 or 20:25,35:25,50:55,60:100)")
 		}
 
 		return nil
 	},
-	Run: func(cmd *cobra.Command, args []string) {
-		var profile string
-		for i, profileNum := range args[1:] {
-			profile += profileNum + " "
-			if (i+1)%2 == 0 {
-				profile += " "
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if cmd.Flags().Changed("fixed") {
+			duty, err := fixedDuty(cmd.ErrOrStderr(), args[0], speedFixed)
+			if err != nil {
+				return err
 			}
+
+			return setFixedSpeed(cmd.OutOrStdout(), args[0], duty, speedInstant)
+		}
+
+		profiles, channels, err := speedProfiles(cmd.InOrStdin(), args)
+		if err != nil {
+			return err
+		}
+
+		curve, err := curveConfig()
+		if err != nil {
+			return err
+		}
+
+		if !speedStrict && !speedLenient {
+			for _, channel := range channels {
+				for _, issue := range curve.ValidateProfile(channel, profiles[channel]) {
+					fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %s profile %s\n", channel, issue)
+				}
+			}
+		}
+
+		if speedPreview {
+			for _, channel := range channels {
+				if err := writeProfileChart(cmd.OutOrStdout(), curve, channel, profiles[channel]); err != nil {
+					return err
+				}
+			}
+
+			return nil
 		}
 
-		profile = strings.Trim(profile, " ")
+		kraken, err := connect(cmd.OutOrStdout())
+		if err != nil {
+			return err
+		}
+		defer kraken.Close()
+		kraken.StrictProfiles = speedStrict
 
-		kraken := driver.NewKrakenDriver()
-		kraken.Connect()
-		kraken.SetSpeed(args[0], profile)
+		for _, channel := range channels {
+			if err := kraken.SetSpeedProfile(channel, profiles[channel]); err != nil {
+				return err
+			}
+		}
+
+		return nil
 	},
 }
 
+// fixedDuty returns the duty `channel` runs at when set to `duty`, warning to `w` if it is clamped, or rejecting it
+// with --strict
+func fixedDuty(w io.Writer, channel string, duty int) (int, error) {
+	applied, err := driver.ClampDuty(channel, duty)
+	if err != nil || applied == duty {
+		return applied, err
+	}
+
+	// --fixed is at most 100, only the minimum clamps
+	reason := fmt.Sprintf("duty %d %% below the %s minimum of %d %%, it runs at %d %%", duty, channel, applied, applied)
+	if speedStrict {
+		return 0, fmt.Errorf("%w for channel %s: %s", driver.ErrInvalidProfile, channel, reason)
+	}
+	if !speedLenient {
+		fmt.Fprintf(w, "Warning: %s %s\n", channel, reason)
+	}
+
+	return applied, nil
+}
+
+// setFixedSpeed sets `duty` on `channel`, as a flat profile if the firmware supports it unless `instant` is set,
+// & reports the method used to `out`
+func setFixedSpeed(out io.Writer, channel string, duty int, instant bool) error {
+	kraken, err := connect(out)
+	if err != nil {
+		return err
+	}
+	defer kraken.Close()
+
+	if instant {
+		if err := kraken.SetInstantSpeed(channel, strconv.Itoa(duty)); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(out, "Set %s to %d %% instantly (forced by --instant), the device does not keep it\n", channel, duty)
+		return nil
+	}
+
+	if dryRun {
+		// nothing is read in a dry run, show what firmware with cooling profiles gets
+		fmt.Fprintln(out, "Dry run: assuming firmware 3.0.0 or later")
+		kraken.FirmwareVersion, kraken.CoolingProfiles = driver.FirmwareVersion{Major: 3}, true
+	}
+
+	supported, err := kraken.SupportsCoolingProfiles()
+	if err != nil {
+		return err
+	}
+
+	if err := kraken.SetFixedSpeed(channel, strconv.Itoa(duty)); err != nil {
+		return err
+	}
+
+	if supported {
+		fmt.Fprintf(out, "Set %s to %d %% as a flat profile (firmware %s supports cooling profiles)\n", channel, duty, kraken.FirmwareVersion)
+	} else {
+		fmt.Fprintf(out, "Set %s to %d %% instantly (firmware %s has no cooling profiles), the device does not keep it\n", channel, duty, kraken.FirmwareVersion)
+	}
+
+	return nil
+}
+
+// speedProfiles returns the profiles to set & the channels to set them on, either from --file or from `args`
+func speedProfiles(stdin io.Reader, args []string) (driver.SpeedProfiles, []string, error) {
+	if speedFile == "" {
+		profile, err := driver.ParseSpeedProfile(strings.Join(args[1:], " "))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return driver.SpeedProfiles{args[0]: profile}, args[:1], nil
+	}
+
+	profiles, err := readSpeedProfiles(stdin, speedFile, speedFormat)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(args) == 0 {
+		return profiles, profiles.Channels(), nil
+	}
+
+	if _, ok := profiles[args[0]]; !ok {
+		return nil, nil, fmt.Errorf("%w: no profile for channel %s in %s", driver.ErrInvalidProfile, args[0], speedFile)
+	}
+
+	return profiles, args[:1], nil
+}
+
+// readSpeedProfiles reads the profiles in `name`, or in `stdin` if `name` is -
+func readSpeedProfiles(stdin io.Reader, name, format string) (driver.SpeedProfiles, error) {
+	if name == "-" {
+		return driver.ReadSpeedProfiles(stdin, format)
+	}
+
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if format == "" {
+		format = driver.ProfileFormat(name)
+	}
+
+	return driver.ReadSpeedProfiles(file, format)
+}
+
 func init() {
 	rootCmd.AddCommand(speedCmd)
+
+	speedCmd.Flags().StringVarP(&speedFile, "file", "f", "", "read the profiles from a YAML, TOML or JSON file, - for stdin")
+	speedCmd.Flags().IntVar(&speedFixed, "fixed", 0, "set a fixed duty instead of a profile (e.g: 40)")
+	speedCmd.Flags().BoolVar(&speedInstant, "instant", false, "set the fixed duty instantly, which the device does not keep, even if the firmware supports profiles")
+	speedCmd.Flags().BoolVar(&speedPreview, "preview", false, "plot the profiles as run by the device instead of setting them")
+	speedCmd.Flags().BoolVar(&speedStrict, "strict", false, "reject profiles & fixed duties that would be clamped or rewritten instead of warning")
+	speedCmd.Flags().BoolVar(&speedLenient, "lenient", false, "set profiles & fixed duties that would be clamped or rewritten without warning")
+	speedCmd.Flags().StringVar(&speedFormat, "format", "", "format of the profile file (yaml, toml or json), guessed by default")
 }
@@ -5,30 +5,133 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/arkste/coolctl/driver"
 )
 
+var (
+	// statusOutputFormat is the format the status is printed in
+	statusOutputFormat string
+
+	// statusWatch keeps printing the status until interrupted
+	statusWatch bool
+
+	// statusInterval is the time between two readings in watch mode
+	statusInterval time.Duration
+
+	// statusCount stops watch mode after this many readings, 0 = never
+	statusCount int
+
+	// statusAppend appends readings instead of refreshing them in place
+	statusAppend bool
+)
+
 // statusCmd represents the status command
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "displays the current status",
-	Run: func(cmd *cobra.Command, args []string) {
-		kraken := driver.NewKrakenDriver()
-		kraken.Connect()
+	Args: func(cmd *cobra.Command, args []string) error {
+		if statusInterval <= 0 {
+			return usageError("the interval must be positive")
+		}
+
+		if statusCount < 0 {
+			return usageError("the count can't be negative")
+		}
+
+		return checkOutputFormat(statusOutputFormat)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		kraken, err := connect(cmd.OutOrStdout())
+		if err != nil {
+			return err
+		}
+		defer kraken.Close()
+
+		if !statusWatch && statusCount == 0 {
+			status, err := kraken.GetStatus()
+			if err != nil {
+				return err
+			}
+
+			return writeStatus(cmd.OutOrStdout(), statusOutputFormat, newStatusOutput(status, kraken))
+		}
+
+		ctx, stop := interruptContext()
+		defer stop()
+
+		out := cmd.OutOrStdout()
+		inPlace := statusOutputFormat == "text" && !statusAppend && out == os.Stdout && isTerminal(os.Stdout)
 
-		temperature, fanSpeed, pumpSpeed, firmwareVersion := kraken.GetStatus()
+		return watchStatus(ctx, kraken, statusInterval, statusCount, func(s statusOutput) error {
+			if inPlace {
+				fmt.Fprint(out, "\033[H\033[2J")
+				return writeStatus(out, statusOutputFormat, s)
+			} else if statusOutputFormat == "text" {
+				return writeStatusLine(out, s)
+			}
 
-		fmt.Println(fmt.Sprintf("  Liquid temperature: %s °C", temperature))
-		fmt.Println(fmt.Sprintf("  Fan speed: %d rpm", fanSpeed))
-		fmt.Println(fmt.Sprintf("  Pump speed: %d rpm", pumpSpeed))
-		fmt.Println(fmt.Sprintf("  Firmware Version: %s", firmwareVersion))
+			return writeStatus(out, statusOutputFormat, s)
+		})
 	},
 }
 
+// watchStatus passes a reading to `print` every `interval` until `ctx` is done or `count` readings were taken
+func watchStatus(ctx context.Context, kraken *driver.KrakenDriver, interval time.Duration, count int, print func(statusOutput) error) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for n := 0; count == 0 || n < count; n++ {
+		if n > 0 {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+
+		status, err := kraken.GetStatusContext(ctx)
+		if ctx.Err() != nil {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if err := print(newStatusOutput(status, kraken)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeStatusLine writes `s` as a single human-readable line
+func writeStatusLine(w io.Writer, s statusOutput) error {
+	_, err := fmt.Fprintf(w, "%s  Liquid temperature: %.1f °C  Fan speed: %d rpm  Pump speed: %d rpm\n",
+		s.Time.Format("15:04:05"), s.LiquidTemperature, s.FanSpeed, s.PumpSpeed)
+
+	return err
+}
+
+// isTerminal checks if `f` is a character device, e.g. a terminal
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
 func init() {
+	statusCmd.Flags().StringVarP(&statusOutputFormat, "output", "o", "text", "output format (text, json, yaml or env)")
+	statusCmd.Flags().BoolVarP(&statusWatch, "watch", "w", false, "keep printing the status until interrupted")
+	statusCmd.Flags().DurationVar(&statusInterval, "interval", time.Second, "time between two readings in watch mode")
+	statusCmd.Flags().IntVar(&statusCount, "count", 0, "stop after this many readings (implies --watch)")
+	statusCmd.Flags().BoolVar(&statusAppend, "append", false, "append readings instead of refreshing them in place")
 	rootCmd.AddCommand(statusCmd)
 }
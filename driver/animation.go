@@ -0,0 +1,317 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"context"
+	"fmt"
+	"image/color"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxFrameRate is the most frames per second an Animator sends, the device can't keep up with more
+const MaxFrameRate = 60
+
+// Frame is the color of every LED at one moment of an animation, the logo first, then the ring
+type Frame [totalLEDs]color.RGBA
+
+// Animation computes the frame at `t` since the animation started
+type Animation func(t time.Duration) Frame
+
+// animationDefaults are the colors & speed an animation runs with if none are given
+type animationDefaults struct {
+	colors []string
+	speed  float64 // cycles per second, e.g. revolutions of a comet or beats of a heart
+	make   func(palette []rgbf, speed float64, seed int64) Animation
+}
+
+// animations are the animations NewAnimation knows
+var animations = map[string]animationDefaults{
+	"comet":     {[]string{"FFFFFF"}, 1, cometAnimation},
+	"fire":      {[]string{"FF1000", "FF6000", "FFC000"}, 8, fireAnimation},
+	"rain":      {[]string{"0060FF"}, 3, rainAnimation},
+	"heartbeat": {[]string{"FF0000"}, 1.2, heartbeatAnimation},
+	"rainbow":   {[]string{"FF0000", "FFFF00", "00FF00", "00FFFF", "0000FF", "FF00FF"}, 0.25, rainbowAnimation},
+}
+
+// Animations lists the names of all animations, sorted
+func Animations() []string {
+	var names []string
+	for name := range animations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// NewAnimation returns the animation `name` in `colors` at `speed` cycles per second, the animation's defaults if
+// they are empty or 0; random animations like fire & rain follow `seed`
+func NewAnimation(name string, colors []string, speed float64, seed int64) (Animation, error) {
+	defaults, ok := animations[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: animation %s (e.g: %s)", ErrUnknownMode, name, strings.Join(Animations(), ", "))
+	}
+
+	if len(colors) == 0 {
+		colors = defaults.colors
+	}
+	if speed <= 0 {
+		speed = defaults.speed
+	}
+
+	colors, err := ExpandColors(colors, ringLEDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var palette []rgbf
+	for _, c := range colors {
+		parsed, err := ParseColor(c)
+		if err != nil {
+			return nil, err
+		}
+		palette = append(palette, rgbf{float64(parsed.R) / 255, float64(parsed.G) / 255, float64(parsed.B) / 255})
+	}
+
+	return defaults.make(palette, speed, seed), nil
+}
+
+// cometAnimation runs a comet with a fading tail around the ring, the logo glowing dimly in its color
+func cometAnimation(palette []rgbf, speed float64, seed int64) Animation {
+	const tail = 4.0 // LEDs
+
+	return func(t time.Duration) Frame {
+		head := math.Mod(t.Seconds()*speed, 1) * ringLEDs
+
+		var f Frame
+		f[0] = rgba(scale(palette[0], 0.25))
+		for led := 0; led < ringLEDs; led++ {
+			behind := math.Mod(head-float64(led)+ringLEDs, ringLEDs)
+			f[led+1] = rgba(scale(palette[0], math.Pow(math.Max(0, 1-behind/tail), 2)))
+		}
+
+		return f
+	}
+}
+
+// fireAnimation flickers every LED through the palette from embers to flames, `speed` times per second
+func fireAnimation(palette []rgbf, speed float64, seed int64) Animation {
+	return func(t time.Duration) Frame {
+		step := t.Seconds() * speed
+		k, frac := math.Floor(step), step-math.Floor(step)
+
+		var f Frame
+		for led := range f {
+			// smooth value noise, so the flames don't jump between frames
+			heat := lerp(noise(seed, led, int64(k)), noise(seed, led, int64(k)+1), frac*frac*(3-2*frac))
+			if len(palette) > 1 {
+				f[led] = rgba(scale(blendStops(palette, heat, BlendRGB), 0.3+0.7*heat))
+			} else {
+				f[led] = rgba(scale(palette[0], 0.3+0.7*heat))
+			}
+		}
+
+		return f
+	}
+}
+
+// rainAnimation lets `speed` drops per second fall on random ring LEDs, fading out after they hit
+func rainAnimation(palette []rgbf, speed float64, seed int64) Animation {
+	const fade = 3.0 // per second
+
+	return func(t time.Duration) Frame {
+		now := t.Seconds()
+
+		var f Frame
+		f[0] = rgba(scale(palette[0], 0.1))
+		for led := 1; led < totalLEDs; led++ {
+			f[led] = rgba(rgbf{})
+		}
+
+		var glow [ringLEDs]float64
+		for drop := int64(math.Floor((now - 2) * speed)); float64(drop) <= now*speed; drop++ {
+			age := now - float64(drop)/speed
+			if drop < 0 || age < 0 {
+				continue
+			}
+
+			led := int(noise(seed, 0, drop) * ringLEDs)
+			if g := math.Exp(-age * fade); g > glow[led] {
+				glow[led] = g
+				f[led+1] = rgba(scale(palette[int(noise(seed, 1, drop)*float64(len(palette)))], g))
+			}
+		}
+
+		return f
+	}
+}
+
+// heartbeatAnimation pulses all LEDs twice, lub-dub, `speed` times per second
+func heartbeatAnimation(palette []rgbf, speed float64, seed int64) Animation {
+	pulse := func(x, at, width float64) float64 {
+		return math.Exp(-math.Pow((x-at)/width, 2))
+	}
+
+	return func(t time.Duration) Frame {
+		beat := math.Mod(t.Seconds()*speed, 1)
+		level := 0.08 + 0.92*math.Max(pulse(beat, 0.1, 0.05), 0.6*pulse(beat, 0.3, 0.05))
+
+		var f Frame
+		for led := range f {
+			f[led] = rgba(scale(palette[0], level))
+		}
+
+		return f
+	}
+}
+
+// rainbowAnimation turns the palette, by default a rainbow, around the ring `speed` times per second
+func rainbowAnimation(palette []rgbf, speed float64, seed int64) Animation {
+	stops := append(append([]rgbf{}, palette...), palette[0])
+
+	return func(t time.Duration) Frame {
+		turn := t.Seconds() * speed
+
+		var f Frame
+		f[0] = rgba(blendStops(stops, math.Mod(turn, 1), BlendHSV))
+		for led := 0; led < ringLEDs; led++ {
+			f[led+1] = rgba(blendStops(stops, math.Mod(turn+float64(led)/ringLEDs, 1), BlendHSV))
+		}
+
+		return f
+	}
+}
+
+// rgba converts `c` to a color as written to the device
+func rgba(c rgbf) color.RGBA {
+	return color.RGBA{R: toByte(c[0]), G: toByte(c[1]), B: toByte(c[2]), A: 1}
+}
+
+// scale dims `c` to `level` in 0-1
+func scale(c rgbf, level float64) rgbf {
+	return rgbf{c[0] * level, c[1] * level, c[2] * level}
+}
+
+// noise returns a pseudo random number in 0-1 for `seed`, `a` & `b`, always the same for the same input
+func noise(seed int64, a int, b int64) float64 {
+	// splitmix64
+	x := uint64(seed) ^ uint64(a)*0x9e3779b97f4a7c15 ^ uint64(b)*0xbf58476d1ce4e5b9
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+
+	return float64(x>>11) / float64(1<<53)
+}
+
+// AnimationStats tells how an Animator kept up with its frame rate
+type AnimationStats struct {
+	Frames   int           // sent to the device
+	Dropped  int           // skipped, as writing the previous frames took too long
+	Duration time.Duration // since the animation started
+	Latency  time.Duration // average time to write a frame
+}
+
+// Animator streams an animation to the device as super-fixed frames, at most FrameRate per second
+type Animator struct {
+	Driver    *KrakenDriver
+	Channel   string // sync, logo or ring
+	Animation Animation
+	FrameRate float64
+	Now       func() time.Time
+	Sleep     func(ctx context.Context, d time.Duration) // returns early once ctx is done
+
+	colorChannel int
+}
+
+// NewAnimator returns an Animator streaming `animation` to `channel` through `d` at `frameRate` frames per second
+func NewAnimator(d *KrakenDriver, channel string, animation Animation, frameRate float64) (*Animator, error) {
+	colorChannel, ok := colorChannels[channel]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownChannel, channel)
+	}
+
+	if frameRate <= 0 || frameRate > MaxFrameRate {
+		return nil, fmt.Errorf("the frame rate must be above 0 and at most %d", MaxFrameRate)
+	}
+
+	return &Animator{
+		Driver:       d,
+		Channel:      channel,
+		Animation:    animation,
+		FrameRate:    frameRate,
+		Now:          time.Now,
+		Sleep:        sleepContext,
+		colorChannel: colorChannel,
+	}, nil
+}
+
+// Run sends frames until `ctx` is done or, unless it is 0, `duration` passed. Frames are due every 1/FrameRate
+// seconds; if writing one takes longer, the frames that fell due meanwhile are dropped instead of queued up.
+func (a *Animator) Run(ctx context.Context, duration time.Duration) (AnimationStats, error) {
+	var stats AnimationStats
+	var writing time.Duration
+
+	interval := time.Duration(float64(time.Second) / a.FrameRate)
+	start := a.Now()
+	due := start
+
+	for ctx.Err() == nil {
+		now := a.Now()
+		stats.Duration = now.Sub(start)
+		if duration > 0 && stats.Duration >= duration {
+			break
+		}
+
+		if err := a.writeFrame(a.Animation(stats.Duration)); err != nil {
+			return stats, err
+		}
+		written := a.Now()
+		writing += written.Sub(now)
+		stats.Frames++
+		stats.Latency = writing / time.Duration(stats.Frames)
+
+		due = due.Add(interval)
+		if behind := written.Sub(due); behind > 0 {
+			missed := int(behind/interval) + 1
+			stats.Dropped += missed
+			due = due.Add(time.Duration(missed) * interval)
+		}
+
+		a.Sleep(ctx, due.Sub(written))
+	}
+
+	return stats, nil
+}
+
+// writeFrame sends `f` as a super-fixed report, without remembering it in the device state
+func (a *Animator) writeFrame(f Frame) error {
+	step := make(color.Palette, len(f))
+	for led, c := range f {
+		step[led] = c
+	}
+
+	return a.Driver.writeColorSteps(a.colorChannel, colorModes["super-fixed"], animationSpeeds["normal"], []color.Palette{step})
+}
+
+// sleepContext waits for `d` or until `ctx` is done
+func sleepContext(ctx context.Context, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
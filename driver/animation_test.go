@@ -0,0 +1,173 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"context"
+	"errors"
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnimations(t *testing.T) {
+	assert.Equal(t, []string{"comet", "fire", "heartbeat", "rain", "rainbow"}, Animations())
+}
+
+func TestNewAnimationErrors(t *testing.T) {
+	_, err := NewAnimation("disco", nil, 0, 1)
+	assert.True(t, errors.Is(err, ErrUnknownMode))
+
+	_, err = NewAnimation("comet", []string{"FF"}, 0, 1)
+	assert.True(t, errors.Is(err, ErrInvalidColor))
+}
+
+// newTestAnimation returns the animation `name`, failing the test if it can't
+func newTestAnimation(t *testing.T, name string, colors []string, speed float64, seed int64) Animation {
+	animation, err := NewAnimation(name, colors, speed, seed)
+	require.Nil(t, err)
+
+	return animation
+}
+
+// rgb returns the RGBA of `rgb` as written to the device
+func rgb(rgb uint32) color.RGBA {
+	return color.RGBA{R: byte(rgb >> 16), G: byte(rgb >> 8), B: byte(rgb), A: 1}
+}
+
+func TestCometAnimation(t *testing.T) {
+	comet := newTestAnimation(t, "comet", []string{"00FF00"}, 1, 0)
+
+	f := comet(0)
+	assert.Equal(t, rgb(0x004000), f[0])
+	assert.Equal(t, rgb(0x00FF00), f[1])
+	assert.Equal(t, rgb(0x000000), f[2])
+	assert.Equal(t, rgb(0x008F00), f[8])
+
+	// one LED further every 1/8 s
+	assert.Equal(t, rgb(0x00FF00), comet(125 * time.Millisecond)[2])
+	assert.Equal(t, comet(0), comet(time.Second))
+}
+
+func TestHeartbeatAnimation(t *testing.T) {
+	heartbeat := newTestAnimation(t, "heartbeat", nil, 1, 0)
+
+	assert.Equal(t, rgb(0xFF0000), heartbeat(100 * time.Millisecond)[4])
+	assert.Equal(t, rgb(0xA10000), heartbeat(300 * time.Millisecond)[0])
+	assert.Equal(t, rgb(0x140000), heartbeat(700 * time.Millisecond)[8])
+}
+
+func TestRainbowAnimation(t *testing.T) {
+	rainbow := newTestAnimation(t, "rainbow", nil, 0.25, 0)
+
+	f := rainbow(0)
+	assert.Equal(t, rgb(0xFF0000), f[0])
+	assert.Equal(t, rgb(0xFF0000), f[1])
+	assert.Equal(t, rgb(0x00FFFF), f[5])
+
+	// a quarter turn after a second
+	assert.Equal(t, f[1], rainbow(time.Second)[7])
+}
+
+func TestRandomAnimations(t *testing.T) {
+	for _, name := range []string{"fire", "rain"} {
+		t.Run(name, func(t *testing.T) {
+			a := newTestAnimation(t, name, nil, 0, 1)
+
+			assert.Equal(t, a(1500*time.Millisecond), newTestAnimation(t, name, nil, 0, 1)(1500*time.Millisecond))
+			assert.NotEqual(t, a(1500*time.Millisecond), newTestAnimation(t, name, nil, 0, 2)(1500*time.Millisecond))
+			assert.NotEqual(t, a(1500*time.Millisecond), a(1750*time.Millisecond))
+		})
+	}
+}
+
+// latencyTransport is a MemoryTransport on a fake clock, where every write takes `latency`
+type latencyTransport struct {
+	*MemoryTransport
+	now     *time.Time
+	latency time.Duration
+}
+
+// Write records `report` & moves the clock on by the latency
+func (t *latencyTransport) Write(report []byte) (int, error) {
+	*t.now = t.now.Add(t.latency)
+	return t.MemoryTransport.Write(report)
+}
+
+// newClockedAnimator returns an Animator on a fake clock that moves only while writing & sleeping
+func newClockedAnimator(t *testing.T, frameRate float64, latency time.Duration) (*Animator, *MemoryTransport) {
+	now := time.Date(2019, 11, 20, 18, 0, 0, 0, time.UTC)
+	transport := &latencyTransport{MemoryTransport: NewMemoryTransport(), now: &now, latency: latency}
+
+	a, err := NewAnimator(NewKrakenDriverWithTransport(transport), "ring", newTestAnimation(t, "comet", nil, 1, 0), frameRate)
+	require.Nil(t, err)
+	a.Now = func() time.Time { return now }
+	a.Sleep = func(ctx context.Context, d time.Duration) {
+		if d > 0 {
+			now = now.Add(d)
+		}
+	}
+
+	return a, transport.MemoryTransport
+}
+
+func TestAnimatorRun(t *testing.T) {
+	a, transport := newClockedAnimator(t, 10, 10*time.Millisecond)
+
+	stats, err := a.Run(context.Background(), time.Second)
+
+	require.Nil(t, err)
+	assert.Equal(t, AnimationStats{Frames: 10, Dropped: 0, Duration: time.Second, Latency: 10 * time.Millisecond}, stats)
+	assert.Len(t, transport.Writes, 10)
+	// super-fixed on the ring, the comet's head on the first ring LED
+	assert.Equal(t, []byte{0x2, 0x4c, 0x2, 0x0, 0x2, 0x40, 0x40, 0x40, 0xff, 0xff, 0xff}, transport.Writes[0][:11])
+}
+
+func TestAnimatorRunDropsFrames(t *testing.T) {
+	a, transport := newClockedAnimator(t, 10, 250*time.Millisecond)
+
+	stats, err := a.Run(context.Background(), time.Second)
+
+	require.Nil(t, err)
+	// frames at 0, 300, 600 & 900 ms, the 2 due while writing each are dropped
+	assert.Equal(t, AnimationStats{Frames: 4, Dropped: 8, Duration: 1200 * time.Millisecond, Latency: 250 * time.Millisecond}, stats)
+	assert.Len(t, transport.Writes, 4)
+}
+
+func TestAnimatorRunCanceled(t *testing.T) {
+	a, transport := newClockedAnimator(t, 10, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	stats, err := a.Run(ctx, 0)
+
+	require.Nil(t, err)
+	assert.Equal(t, 0, stats.Frames)
+	assert.Empty(t, transport.Writes)
+}
+
+func TestAnimatorNotRecorded(t *testing.T) {
+	a, _ := newClockedAnimator(t, 10, 0)
+	a.Driver.State = &DeviceState{}
+
+	_, err := a.Run(context.Background(), 500*time.Millisecond)
+
+	require.Nil(t, err)
+	assert.Empty(t, a.Driver.State.Color)
+}
+
+func TestNewAnimatorErrors(t *testing.T) {
+	kraken := NewKrakenDriverWithTransport(NewMemoryTransport())
+	comet := newTestAnimation(t, "comet", nil, 1, 0)
+
+	_, err := NewAnimator(kraken, "case", comet, 20)
+	assert.True(t, errors.Is(err, ErrUnknownChannel))
+
+	_, err = NewAnimator(kraken, "ring", comet, MaxFrameRate+1)
+	assert.Error(t, err)
+}
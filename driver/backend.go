@@ -0,0 +1,66 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Backend finds devices & opens transports to them
+type Backend interface {
+	// Devices lists all connected devices matching `vid` & `pid`
+	Devices(vid, pid uint16) ([]DeviceInfo, error)
+	// Open opens the device described by `info`
+	Open(info DeviceInfo) (Transport, error)
+}
+
+// DeviceInfo describes a connected device
+type DeviceInfo struct {
+	Backend      string `json:"backend" yaml:"backend"`
+	Bus          int    `json:"bus" yaml:"bus"`
+	PortPath     string `json:"port_path" yaml:"port_path"` // ports from the root hub joined by dots, e.g. 1.4
+	Address      int    `json:"address" yaml:"address"`
+	Manufacturer string `json:"manufacturer" yaml:"manufacturer"`
+	Product      string `json:"product" yaml:"product"`
+	SerialNumber string `json:"serial_number" yaml:"serial_number"`
+	Path         string `json:"path,omitempty" yaml:"path,omitempty"`
+}
+
+// backends holds all backends available in this build, by name
+var backends = map[string]Backend{}
+
+// Backends returns the names of all backends available in this build
+func Backends() []string {
+	var names []string
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// lookupBackend returns the backend called `name`
+func lookupBackend(name string) (Backend, error) {
+	backend, ok := backends[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownBackend, name)
+	}
+
+	return backend, nil
+}
+
+// formatPortPath joins the port numbers from the root hub to the device like sysfs does, e.g. 1.4
+func formatPortPath(ports []int) string {
+	parts := make([]string, len(ports))
+	for i, port := range ports {
+		parts[i] = strconv.Itoa(port)
+	}
+
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,33 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackends(t *testing.T) {
+	assert.Contains(t, Backends(), "usb")
+	assert.Contains(t, Backends(), DefaultBackend)
+}
+
+func TestConnectUnknownBackend(t *testing.T) {
+	kraken := NewKrakenDriver()
+	kraken.Backend = "serial"
+
+	err := kraken.Connect()
+
+	assert.True(t, errors.Is(err, ErrUnknownBackend))
+	assert.Nil(t, kraken.Transport)
+}
+
+func TestFormatPortPath(t *testing.T) {
+	assert.Equal(t, "4", formatPortPath([]int{4}))
+	assert.Equal(t, "1.4.2", formatPortPath([]int{1, 4, 2}))
+	assert.Equal(t, "", formatPortPath(nil))
+}
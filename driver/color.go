@@ -0,0 +1,314 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	minKelvin = 1000
+	maxKelvin = 40000
+)
+
+// colorNames are the CSS color names, which are mostly the X11 ones
+var colorNames = map[string]uint32{
+	"aliceblue":            0xf0f8ff,
+	"antiquewhite":         0xfaebd7,
+	"aqua":                 0x00ffff,
+	"aquamarine":           0x7fffd4,
+	"azure":                0xf0ffff,
+	"beige":                0xf5f5dc,
+	"bisque":               0xffe4c4,
+	"black":                0x000000,
+	"blanchedalmond":       0xffebcd,
+	"blue":                 0x0000ff,
+	"blueviolet":           0x8a2be2,
+	"brown":                0xa52a2a,
+	"burlywood":            0xdeb887,
+	"cadetblue":            0x5f9ea0,
+	"chartreuse":           0x7fff00,
+	"chocolate":            0xd2691e,
+	"coral":                0xff7f50,
+	"cornflowerblue":       0x6495ed,
+	"cornsilk":             0xfff8dc,
+	"crimson":              0xdc143c,
+	"cyan":                 0x00ffff,
+	"darkblue":             0x00008b,
+	"darkcyan":             0x008b8b,
+	"darkgoldenrod":        0xb8860b,
+	"darkgray":             0xa9a9a9,
+	"darkgreen":            0x006400,
+	"darkgrey":             0xa9a9a9,
+	"darkkhaki":            0xbdb76b,
+	"darkmagenta":          0x8b008b,
+	"darkolivegreen":       0x556b2f,
+	"darkorange":           0xff8c00,
+	"darkorchid":           0x9932cc,
+	"darkred":              0x8b0000,
+	"darksalmon":           0xe9967a,
+	"darkseagreen":         0x8fbc8f,
+	"darkslateblue":        0x483d8b,
+	"darkslategray":        0x2f4f4f,
+	"darkslategrey":        0x2f4f4f,
+	"darkturquoise":        0x00ced1,
+	"darkviolet":           0x9400d3,
+	"deeppink":             0xff1493,
+	"deepskyblue":          0x00bfff,
+	"dimgray":              0x696969,
+	"dimgrey":              0x696969,
+	"dodgerblue":           0x1e90ff,
+	"firebrick":            0xb22222,
+	"floralwhite":          0xfffaf0,
+	"forestgreen":          0x228b22,
+	"fuchsia":              0xff00ff,
+	"gainsboro":            0xdcdcdc,
+	"ghostwhite":           0xf8f8ff,
+	"gold":                 0xffd700,
+	"goldenrod":            0xdaa520,
+	"gray":                 0x808080,
+	"green":                0x008000,
+	"greenyellow":          0xadff2f,
+	"grey":                 0x808080,
+	"honeydew":             0xf0fff0,
+	"hotpink":              0xff69b4,
+	"indianred":            0xcd5c5c,
+	"indigo":               0x4b0082,
+	"ivory":                0xfffff0,
+	"khaki":                0xf0e68c,
+	"lavender":             0xe6e6fa,
+	"lavenderblush":        0xfff0f5,
+	"lawngreen":            0x7cfc00,
+	"lemonchiffon":         0xfffacd,
+	"lightblue":            0xadd8e6,
+	"lightcoral":           0xf08080,
+	"lightcyan":            0xe0ffff,
+	"lightgoldenrodyellow": 0xfafad2,
+	"lightgray":            0xd3d3d3,
+	"lightgreen":           0x90ee90,
+	"lightgrey":            0xd3d3d3,
+	"lightpink":            0xffb6c1,
+	"lightsalmon":          0xffa07a,
+	"lightseagreen":        0x20b2aa,
+	"lightskyblue":         0x87cefa,
+	"lightslategray":       0x778899,
+	"lightslategrey":       0x778899,
+	"lightsteelblue":       0xb0c4de,
+	"lightyellow":          0xffffe0,
+	"lime":                 0x00ff00,
+	"limegreen":            0x32cd32,
+	"linen":                0xfaf0e6,
+	"magenta":              0xff00ff,
+	"maroon":               0x800000,
+	"mediumaquamarine":     0x66cdaa,
+	"mediumblue":           0x0000cd,
+	"mediumorchid":         0xba55d3,
+	"mediumpurple":         0x9370db,
+	"mediumseagreen":       0x3cb371,
+	"mediumslateblue":      0x7b68ee,
+	"mediumspringgreen":    0x00fa9a,
+	"mediumturquoise":      0x48d1cc,
+	"mediumvioletred":      0xc71585,
+	"midnightblue":         0x191970,
+	"mintcream":            0xf5fffa,
+	"mistyrose":            0xffe4e1,
+	"moccasin":             0xffe4b5,
+	"navajowhite":          0xffdead,
+	"navy":                 0x000080,
+	"oldlace":              0xfdf5e6,
+	"olive":                0x808000,
+	"olivedrab":            0x6b8e23,
+	"orange":               0xffa500,
+	"orangered":            0xff4500,
+	"orchid":               0xda70d6,
+	"palegoldenrod":        0xeee8aa,
+	"palegreen":            0x98fb98,
+	"paleturquoise":        0xafeeee,
+	"palevioletred":        0xdb7093,
+	"papayawhip":           0xffefd5,
+	"peachpuff":            0xffdab9,
+	"peru":                 0xcd853f,
+	"pink":                 0xffc0cb,
+	"plum":                 0xdda0dd,
+	"powderblue":           0xb0e0e6,
+	"purple":               0x800080,
+	"rebeccapurple":        0x663399,
+	"red":                  0xff0000,
+	"rosybrown":            0xbc8f8f,
+	"royalblue":            0x4169e1,
+	"saddlebrown":          0x8b4513,
+	"salmon":               0xfa8072,
+	"sandybrown":           0xf4a460,
+	"seagreen":             0x2e8b57,
+	"seashell":             0xfff5ee,
+	"sienna":               0xa0522d,
+	"silver":               0xc0c0c0,
+	"skyblue":              0x87ceeb,
+	"slateblue":            0x6a5acd,
+	"slategray":            0x708090,
+	"slategrey":            0x708090,
+	"snow":                 0xfffafa,
+	"springgreen":          0x00ff7f,
+	"steelblue":            0x4682b4,
+	"tan":                  0xd2b48c,
+	"teal":                 0x008080,
+	"thistle":              0xd8bfd8,
+	"tomato":               0xff6347,
+	"turquoise":            0x40e0d0,
+	"violet":               0xee82ee,
+	"wheat":                0xf5deb3,
+	"white":                0xffffff,
+	"whitesmoke":           0xf5f5f5,
+	"yellow":               0xffff00,
+	"yellowgreen":          0x9acd32,
+}
+
+// ParseColor parses a color given as a CSS/X11 name (red, light-sea-green), hex code (FF0000, #F00),
+// rgb(255,0,0), hsl(120,100%,50%) or color temperature (2700K)
+func ParseColor(s string) (*color.RGBA, error) {
+	c, err := parseColor(strings.ToLower(strings.TrimSpace(s)))
+	if err != nil {
+		return nil, fmt.Errorf("%w %q: %v", ErrInvalidColor, s, err)
+	}
+
+	return c, nil
+}
+
+// parseColor parses a trimmed, lower case color, see ParseColor
+func parseColor(s string) (*color.RGBA, error) {
+	name := strings.NewReplacer(" ", "", "-", "", "_", "").Replace(s)
+	if rgb, ok := colorNames[name]; ok {
+		return &color.RGBA{R: byte(rgb >> 16), G: byte(rgb >> 8), B: byte(rgb), A: 1}, nil
+	}
+
+	switch {
+	case s == "":
+		return nil, errors.New("no color given")
+	case strings.HasPrefix(s, "rgb("):
+		return parseRGBFunction(s)
+	case strings.HasPrefix(s, "hsl("):
+		return parseHSLFunction(s)
+	case strings.HasSuffix(s, "k") && strings.Trim(s[:len(s)-1], "0123456789") == "" && len(s) > 1:
+		return parseKelvin(s[:len(s)-1])
+	case strings.Trim(strings.TrimPrefix(s, "#"), "0123456789abcdef") == "":
+		return colorFromHexString(strings.TrimPrefix(s, "#"))
+	}
+
+	return nil, errors.New("not a color name, hex code (#FF0000 or #F00), rgb(), hsl() or color temperature (2700K)")
+}
+
+// functionArgs returns the 3 comma separated arguments of a function like rgb(255, 0, 0)
+func functionArgs(s, function string) ([]string, error) {
+	if !strings.HasSuffix(s, ")") {
+		return nil, fmt.Errorf("%s() is missing its closing parenthesis", function)
+	}
+
+	args := strings.Split(s[len(function)+1:len(s)-1], ",")
+	if len(args) != 3 {
+		return nil, fmt.Errorf("%s() takes 3 values, not %d", function, len(args))
+	}
+	for i := range args {
+		args[i] = strings.TrimSpace(args[i])
+	}
+
+	return args, nil
+}
+
+// parseRGBFunction parses rgb(255, 0, 0) or rgb(100%, 0%, 0%)
+func parseRGBFunction(s string) (*color.RGBA, error) {
+	args, err := functionArgs(s, "rgb")
+	if err != nil {
+		return nil, err
+	}
+
+	var rgb [3]byte
+	for i, arg := range args {
+		max := 255.0
+		if strings.HasSuffix(arg, "%") {
+			arg, max = strings.TrimSuffix(arg, "%"), 100
+		}
+
+		v, err := strconv.ParseFloat(arg, 64)
+		if err != nil || math.IsNaN(v) || v < 0 || v > max {
+			return nil, fmt.Errorf("rgb() value %q must be between 0 and 255, or 0%% and 100%%", args[i])
+		}
+		rgb[i] = byte(math.Round(v / max * 255))
+	}
+
+	return &color.RGBA{R: rgb[0], G: rgb[1], B: rgb[2], A: 1}, nil
+}
+
+// parseHSLFunction parses hsl(120, 100%, 50%), the hue in degrees
+func parseHSLFunction(s string) (*color.RGBA, error) {
+	args, err := functionArgs(s, "hsl")
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := strconv.ParseFloat(strings.TrimSuffix(args[0], "deg"), 64)
+	if err != nil || math.IsNaN(h) || math.IsInf(h, 0) {
+		return nil, fmt.Errorf("hsl() hue %q is not a number of degrees", args[0])
+	}
+
+	var sl [2]float64
+	for i, arg := range args[1:] {
+		v, err := strconv.ParseFloat(strings.TrimSuffix(arg, "%"), 64)
+		if err != nil || math.IsNaN(v) || v < 0 || v > 100 {
+			return nil, fmt.Errorf("hsl() saturation & lightness %q must be between 0%% and 100%%", arg)
+		}
+		sl[i] = v / 100
+	}
+
+	r, g, b := hslToRGB(math.Mod(math.Mod(h, 360)+360, 360), sl[0], sl[1])
+
+	return &color.RGBA{R: r, G: g, B: b, A: 1}, nil
+}
+
+// hslToRGB converts a hue in degrees, saturation & lightness in 0-1 to RGB, by way of HSV
+func hslToRGB(h, s, l float64) (byte, byte, byte) {
+	v := l + s*math.Min(l, 1-l)
+	sv := 0.0
+	if v > 0 {
+		sv = 2 * (1 - l/v)
+	}
+
+	c := hsvToRGB(h, sv, v)
+
+	return toByte(c[0]), toByte(c[1]), toByte(c[2])
+}
+
+// parseKelvin approximates the color of a black body at a temperature in kelvin, after Tanner Helland
+func parseKelvin(s string) (*color.RGBA, error) {
+	k, err := strconv.Atoi(s)
+	if err != nil || k < minKelvin || k > maxKelvin {
+		return nil, fmt.Errorf("color temperatures must be between %dK and %dK", minKelvin, maxKelvin)
+	}
+
+	t := float64(k) / 100
+	r, g, b := 255.0, 255.0, 255.0
+	if t <= 66 {
+		g = 99.4708025861*math.Log(t) - 161.1195681661
+		if t <= 19 {
+			b = 0
+		} else if t < 66 {
+			b = 138.5177312231*math.Log(t-10) - 305.0447927307
+		}
+	} else {
+		r = 329.698727446 * math.Pow(t-60, -0.1332047592)
+		g = 288.1221695283 * math.Pow(t-60, -0.0755148492)
+	}
+
+	return &color.RGBA{R: toByte(r / 255), G: toByte(g / 255), B: toByte(b / 255), A: 1}, nil
+}
+
+// toByte converts a color component in 0-1 to 0-255, clamping it
+func toByte(v float64) byte {
+	return byte(math.Round(math.Max(0, math.Min(1, v)) * 255))
+}
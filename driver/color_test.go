@@ -0,0 +1,79 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"errors"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var parseColorTests = []struct {
+	in  string
+	out color.RGBA
+}{
+	{"FF0000", color.RGBA{255, 0, 0, 1}},
+	{"#00ff00", color.RGBA{0, 255, 0, 1}},
+	{"#F80", color.RGBA{255, 136, 0, 1}},
+	{"00f", color.RGBA{0, 0, 255, 1}},
+	{"red", color.RGBA{255, 0, 0, 1}},
+	{"RebeccaPurple", color.RGBA{102, 51, 153, 1}},
+	{"light-sea-green", color.RGBA{32, 178, 170, 1}},
+	{"dark slate grey", color.RGBA{47, 79, 79, 1}},
+	{"rgb(255,128,0)", color.RGBA{255, 128, 0, 1}},
+	{"RGB( 0, 100%, 50% )", color.RGBA{0, 255, 128, 1}},
+	{"hsl(120,100%,50%)", color.RGBA{0, 255, 0, 1}},
+	{"hsl(-120deg, 100%, 25%)", color.RGBA{0, 0, 128, 1}},
+	{"hsl(0, 0%, 100%)", color.RGBA{255, 255, 255, 1}},
+	{"hsl(200,50%,40%)", color.RGBA{51, 119, 153, 1}},
+	{"2700K", color.RGBA{255, 167, 87, 1}},
+	{"6600k", color.RGBA{255, 255, 255, 1}},
+	{"10000K", color.RGBA{202, 218, 255, 1}},
+}
+
+func TestParseColor(t *testing.T) {
+	for _, tt := range parseColorTests {
+		t.Run(tt.in, func(t *testing.T) {
+			c, err := ParseColor(tt.in)
+
+			assert.Nil(t, err)
+			assert.Equal(t, &tt.out, c)
+		})
+	}
+}
+
+var parseColorInvalidTests = []struct {
+	in  string
+	err string
+}{
+	{"", `invalid color "": no color given`},
+	{"FF", `invalid color "FF": hex colors have 3 or 6 digits, not 2`},
+	{"#FF00", `invalid color "#FF00": hex colors have 3 or 6 digits, not 4`},
+	{"#", `invalid color "#": hex colors have 3 or 6 digits, not 0`},
+	{"foobar", `invalid color "foobar": not a color name`},
+	{"rgb(255,0)", `invalid color "rgb(255,0)": rgb() takes 3 values, not 2`},
+	{"rgb(256,0,0)", `invalid color "rgb(256,0,0)": rgb() value "256" must be between 0 and 255`},
+	{"rgb(255,0,0", `invalid color "rgb(255,0,0": rgb() is missing its closing parenthesis`},
+	{"hsl(red,100%,50%)", `invalid color "hsl(red,100%,50%)": hsl() hue "red" is not a number of degrees`},
+	{"hsl(inf,100%,50%)", `invalid color "hsl(inf,100%,50%)": hsl() hue "inf" is not a number of degrees`},
+	{"rgb(nan,0,0)", `invalid color "rgb(nan,0,0)": rgb() value "nan" must be between 0 and 255`},
+	{"hsl(0,120%,50%)", `invalid color "hsl(0,120%,50%)": hsl() saturation & lightness "120%" must be between 0% and 100%`},
+	{"500K", `invalid color "500K": color temperatures must be between 1000K and 40000K`},
+	{"K", `invalid color "K": not a color name`},
+}
+
+func TestParseColorInvalid(t *testing.T) {
+	for _, tt := range parseColorInvalidTests {
+		t.Run(tt.in, func(t *testing.T) {
+			c, err := ParseColor(tt.in)
+
+			assert.Nil(t, c)
+			assert.True(t, errors.Is(err, ErrInvalidColor))
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
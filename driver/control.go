@@ -0,0 +1,242 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// ControlInstant sets duties with instant speed reports, which the device does not persist
+	ControlInstant = "instant"
+	// ControlFlat uploads a flat profile at the duty, which the device keeps following on its own
+	ControlFlat = "flat"
+)
+
+// TemperatureSensor is the input a Controller evaluates its curves against
+type TemperatureSensor interface {
+	ReadTemperature() (float64, error)
+}
+
+// ControlReading is the outcome of a single Controller tick
+type ControlReading struct {
+	Time        time.Time
+	Temperature float64        // °C, as read from the sensor
+	Duties      map[string]int // per speed channel, as applied
+}
+
+// ControlTuning keeps a Controller from hunting on a noisy temperature, zero values disable each stage
+type ControlTuning struct {
+	Smoothing  time.Duration // time constant of the moving average over the temperature
+	Hysteresis float64       // °C the smoothed temperature must move before the duty follows
+	RampUp     float64       // %/s the duty may rise at most
+	RampDown   float64       // %/s the duty may fall at most
+}
+
+// controlState is what a Controller remembers about a speed channel between ticks
+type controlState struct {
+	time   time.Time
+	temp   float64 // smoothed
+	anchor float64 // smoothed temperature the duty follows, moves only beyond the hysteresis
+	duty   float64 // ramp limited
+}
+
+// Controller drives speed channels from software curves evaluated against a sensor instead of the liquid temperature
+type Controller struct {
+	Driver *KrakenDriver
+	Sensor TemperatureSensor
+	Curves SpeedProfiles
+	Tuning map[string]ControlTuning // per speed channel
+	Method string                   // ControlInstant or ControlFlat
+	Now    func() time.Time
+
+	states map[string]*controlState
+	duties map[string]int // last applied per speed channel
+}
+
+// NewController returns a Controller driving the channels of `curves` from `sensor` through `d`
+func NewController(d *KrakenDriver, sensor TemperatureSensor, curves SpeedProfiles) (*Controller, error) {
+	c := &Controller{
+		Driver: d,
+		Sensor: sensor,
+		Curves: SpeedProfiles{},
+		Tuning: map[string]ControlTuning{},
+		Method: ControlInstant,
+		Now:    time.Now,
+		states: map[string]*controlState{},
+		duties: map[string]int{},
+	}
+
+	for channel, curve := range curves {
+		if _, ok := speedChannels[channel]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownChannel, channel)
+		}
+
+		if len(curve) == 0 {
+			return nil, fmt.Errorf("%w: empty profile for channel %s", ErrInvalidProfile, channel)
+		}
+
+		// the sensor is no liquid, the curve is neither fit to the grid nor forced to full speed at the critical
+		// temperature; sort a copy to leave the caller's curve alone
+		sorted := append(SpeedProfile{}, curve...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i][0] < sorted[j][0]
+		})
+		c.Curves[channel] = sorted
+	}
+
+	return c, nil
+}
+
+// Run ticks immediately & then every `interval` until `ctx` is done, passing every reading to `report`;
+// if the sensor can't be read, all channels are set to full speed before giving up
+func (c *Controller) Run(ctx context.Context, interval time.Duration, count int, report func(ControlReading) error) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for n := 0; count == 0 || n < count; n++ {
+		if n > 0 {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+
+		reading, err := c.Tick()
+		if err != nil {
+			return err
+		}
+
+		if err := report(reading); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Tick reads the sensor once & applies the duties the curves yield for its temperature
+func (c *Controller) Tick() (ControlReading, error) {
+	reading := ControlReading{Time: c.Now(), Duties: map[string]int{}}
+
+	temp, err := c.Sensor.ReadTemperature()
+	if err != nil {
+		if ferr := c.failSafe(); ferr != nil {
+			return reading, fmt.Errorf("reading temperature failed: %v, setting full speed failed: %w", err, ferr)
+		}
+		return reading, fmt.Errorf("reading temperature failed, set full speed: %w", err)
+	}
+	reading.Temperature = temp
+
+	for _, channel := range c.Curves.Channels() {
+		duty := c.target(channel, temp, reading.Time)
+
+		if err := c.apply(channel, duty); err != nil {
+			return reading, err
+		}
+		reading.Duties[channel] = c.duties[channel]
+	}
+
+	return reading, nil
+}
+
+// target smooths `temp`, applies the hysteresis, evaluates the curve & limits the ramp for `channel`
+func (c *Controller) target(channel string, temp float64, now time.Time) int {
+	tuning := c.Tuning[channel]
+
+	state, ok := c.states[channel]
+	if !ok {
+		// ramps start from a duty the device can actually run at
+		duty := float64(clampDuty(channel, dutyAt(c.Curves[channel], temp)))
+		c.states[channel] = &controlState{time: now, temp: temp, anchor: temp, duty: duty}
+		return int(math.Round(duty))
+	}
+
+	dt := now.Sub(state.time).Seconds()
+	state.time = now
+
+	if tuning.Smoothing > 0 {
+		state.temp = approach(state.temp, temp, dt, tuning.Smoothing.Seconds())
+	} else {
+		state.temp = temp
+	}
+
+	if math.Abs(state.temp-state.anchor) >= tuning.Hysteresis {
+		state.anchor = state.temp
+	}
+
+	duty := float64(clampDuty(channel, dutyAt(c.Curves[channel], state.anchor)))
+	if tuning.RampUp > 0 && duty > state.duty {
+		duty = math.Min(duty, state.duty+tuning.RampUp*dt)
+	} else if tuning.RampDown > 0 && duty < state.duty {
+		duty = math.Max(duty, state.duty-tuning.RampDown*dt)
+	}
+	state.duty = duty
+
+	return int(math.Round(duty))
+}
+
+// apply sets `duty` on `channel` with the configured method, unless it is already set
+func (c *Controller) apply(channel string, duty int) error {
+	duty = clampDuty(channel, duty)
+
+	if last, ok := c.duties[channel]; ok && last == duty {
+		return nil
+	}
+
+	var err error
+	switch c.Method {
+	case ControlInstant:
+		err = c.Driver.setInstantDuty(channel, duty)
+	case ControlFlat:
+		_, err = c.Driver.setSpeedProfile(channel, flatProfile(c.Driver.Curve, duty))
+	default:
+		return fmt.Errorf("unknown control method %q", c.Method)
+	}
+	if err != nil {
+		return err
+	}
+
+	c.duties[channel] = duty
+
+	return nil
+}
+
+// failSafe sets all channels to full speed, bypassing the ramp limits
+func (c *Controller) failSafe() error {
+	for _, channel := range c.Curves.Channels() {
+		if err := c.apply(channel, 100); err != nil {
+			return err
+		}
+
+		if state, ok := c.states[channel]; ok {
+			state.duty = 100
+		}
+	}
+
+	return nil
+}
+
+// dutyAt linearly interpolates the duty of the sorted curve `p` at `temp`, holding its first & last duty outside it
+func dutyAt(p SpeedProfile, temp float64) int {
+	if temp <= float64(p[0][0]) {
+		return p[0][1]
+	}
+
+	for i := 1; i < len(p); i++ {
+		lower, upper := p[i-1], p[i]
+		if temp <= float64(upper[0]) {
+			ratio := (temp - float64(lower[0])) / float64(upper[0]-lower[0])
+			return int(math.Round(float64(lower[1]) + ratio*float64(upper[1]-lower[1])))
+		}
+	}
+
+	return p[len(p)-1][1]
+}
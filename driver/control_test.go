@@ -0,0 +1,257 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSensor returns its temperature, or its error if set
+type fakeSensor struct {
+	temp float64
+	err  error
+}
+
+func (s *fakeSensor) ReadTemperature() (float64, error) {
+	return s.temp, s.err
+}
+
+var cpuCurves = SpeedProfiles{
+	"fan":  {{30, 25}, {50, 50}, {58, 100}},
+	"pump": {{30, 60}, {50, 80}},
+}
+
+var controllerTests = []struct {
+	temp float64
+	fan  int
+	pump int
+}{
+	{20, 25, 60},
+	{40, 38, 70},
+	{45, 44, 75},
+	{53, 69, 80},
+	{59, 100, 80},
+	{75, 100, 80},
+}
+
+func TestControllerTick(t *testing.T) {
+	for _, tt := range controllerTests {
+		sim := NewSimulator()
+		sensor := &fakeSensor{temp: tt.temp}
+
+		c, err := NewController(NewKrakenDriverWithTransport(sim), sensor, cpuCurves)
+		require.Nil(t, err)
+
+		reading, err := c.Tick()
+		require.Nil(t, err)
+
+		assert.Equal(t, tt.temp, reading.Temperature)
+		assert.Equal(t, map[string]int{"fan": tt.fan, "pump": tt.pump}, reading.Duties, "%.1f °C", tt.temp)
+		assert.Equal(t, tt.fan, sim.Duty("fan"))
+		assert.Equal(t, tt.pump, sim.Duty("pump"))
+	}
+}
+
+// cpuTempTests follow a curve with points above the critical liquid temperature
+var cpuTempTests = []struct {
+	temp float64
+	fan  int
+}{
+	{25, 25},
+	{61, 78},
+	{65, 88},
+	{70, 100},
+	{90, 100},
+}
+
+func TestControllerAboveCriticalTemp(t *testing.T) {
+	for _, tt := range cpuTempTests {
+		sim := NewSimulator()
+		c, err := NewController(NewKrakenDriverWithTransport(sim), &fakeSensor{temp: tt.temp}, SpeedProfiles{"fan": {{70, 100}, {30, 25}, {50, 50}}})
+		require.Nil(t, err)
+
+		reading, err := c.Tick()
+
+		require.Nil(t, err)
+		assert.Equal(t, tt.fan, reading.Duties["fan"], "%.1f °C", tt.temp)
+	}
+}
+
+func TestControllerClampsDuties(t *testing.T) {
+	sim := NewSimulator()
+	c, err := NewController(NewKrakenDriverWithTransport(sim), &fakeSensor{temp: 20}, SpeedProfiles{"pump": {{20, 0}, {60, 100}}})
+	require.Nil(t, err)
+
+	reading, err := c.Tick()
+
+	require.Nil(t, err)
+	assert.Equal(t, 50, reading.Duties["pump"])
+}
+
+func TestControllerWritesOnlyChanges(t *testing.T) {
+	transport := NewMemoryTransport()
+	sensor := &fakeSensor{temp: 40}
+	c, err := NewController(NewKrakenDriverWithTransport(transport), sensor, SpeedProfiles{"fan": cpuCurves["fan"]})
+	require.Nil(t, err)
+
+	_, err = c.Tick()
+	require.Nil(t, err)
+	_, err = c.Tick()
+	require.Nil(t, err)
+	assert.Len(t, transport.Writes, 1)
+
+	sensor.temp = 50
+	_, err = c.Tick()
+	require.Nil(t, err)
+	assert.Len(t, transport.Writes, 2)
+	assert.Equal(t, []byte{0x2, 0x4d, 0x00, 0x00, 50}, transport.Writes[1][:5])
+}
+
+func TestControllerFlatMethod(t *testing.T) {
+	sim := NewSimulator()
+	c, err := NewController(NewKrakenDriverWithTransport(sim), &fakeSensor{temp: 50}, SpeedProfiles{"fan": cpuCurves["fan"]})
+	require.Nil(t, err)
+	c.Method = ControlFlat
+
+	_, err = c.Tick()
+	require.Nil(t, err)
+
+	assert.Len(t, sim.Curves["fan"], 21)
+	assert.Equal(t, []int{20, 50}, sim.Curves["fan"][0])
+	assert.Equal(t, []int{58, 50}, sim.Curves["fan"][19])
+	assert.Equal(t, []int{60, 100}, sim.Curves["fan"][20])
+}
+
+func TestControllerFailSafe(t *testing.T) {
+	sim := NewSimulator()
+	sensor := &fakeSensor{temp: 30}
+	c, err := NewController(NewKrakenDriverWithTransport(sim), sensor, cpuCurves)
+	require.Nil(t, err)
+
+	_, err = c.Tick()
+	require.Nil(t, err)
+
+	sensor.err = errors.New("sensor gone")
+	_, err = c.Tick()
+
+	assert.Error(t, err)
+	assert.Equal(t, 100, sim.Duty("fan"))
+	assert.Equal(t, 100, sim.Duty("pump"))
+}
+
+func TestControllerRun(t *testing.T) {
+	sim := NewSimulator()
+	c, err := NewController(NewKrakenDriverWithTransport(sim), &fakeSensor{temp: 40}, cpuCurves)
+	require.Nil(t, err)
+
+	var readings []ControlReading
+	err = c.Run(context.Background(), time.Millisecond, 3, func(r ControlReading) error {
+		readings = append(readings, r)
+		return nil
+	})
+
+	assert.Nil(t, err)
+	assert.Len(t, readings, 3)
+}
+
+func TestNewControllerInvalid(t *testing.T) {
+	_, err := NewController(NewKrakenDriverWithTransport(NewSimulator()), &fakeSensor{}, SpeedProfiles{"case": {{20, 25}}})
+	assert.True(t, errors.Is(err, ErrUnknownChannel))
+
+	_, err = NewController(NewKrakenDriverWithTransport(NewSimulator()), &fakeSensor{}, SpeedProfiles{"fan": {}})
+	assert.True(t, errors.Is(err, ErrInvalidProfile))
+}
+
+// newClockedController returns a Controller on a simulator whose clock only moves when the returned tick function is called
+func newClockedController(t *testing.T, sensor TemperatureSensor, tuning ControlTuning) (*Controller, func(time.Duration) ControlReading) {
+	c, err := NewController(NewKrakenDriverWithTransport(NewSimulator()), sensor, SpeedProfiles{"fan": {{30, 25}, {50, 75}, {60, 100}}})
+	require.Nil(t, err)
+	c.Tuning["fan"] = tuning
+
+	now := time.Date(2019, 11, 20, 18, 0, 0, 0, time.UTC)
+	c.Now = func() time.Time { return now }
+
+	return c, func(d time.Duration) ControlReading {
+		now = now.Add(d)
+		reading, err := c.Tick()
+		require.Nil(t, err)
+		return reading
+	}
+}
+
+func TestControllerHysteresis(t *testing.T) {
+	sensor := &fakeSensor{temp: 40}
+	_, tick := newClockedController(t, sensor, ControlTuning{Hysteresis: 2})
+
+	assert.Equal(t, 50, tick(0).Duties["fan"])
+
+	for _, temp := range []float64{41, 39, 41.5, 38.5} {
+		sensor.temp = temp
+		assert.Equal(t, 50, tick(time.Second).Duties["fan"], "%.1f °C", temp)
+	}
+
+	sensor.temp = 42
+	assert.Equal(t, 55, tick(time.Second).Duties["fan"])
+
+	sensor.temp = 40.5
+	assert.Equal(t, 55, tick(time.Second).Duties["fan"])
+
+	sensor.temp = 40
+	assert.Equal(t, 50, tick(time.Second).Duties["fan"])
+}
+
+func TestControllerSmoothing(t *testing.T) {
+	sensor := &fakeSensor{temp: 30}
+	_, tick := newClockedController(t, sensor, ControlTuning{Smoothing: 10 * time.Second})
+
+	assert.Equal(t, 25, tick(0).Duties["fan"])
+
+	// a single spike barely moves the duty
+	sensor.temp = 50
+	assert.Equal(t, 30, tick(time.Second).Duties["fan"])
+	sensor.temp = 30
+	assert.Equal(t, 29, tick(time.Second).Duties["fan"])
+
+	// a lasting rise is followed after a few time constants
+	sensor.temp = 50
+	tick(time.Minute)
+	assert.Equal(t, 75, tick(time.Second).Duties["fan"])
+}
+
+func TestControllerRampLimits(t *testing.T) {
+	sensor := &fakeSensor{temp: 30}
+	_, tick := newClockedController(t, sensor, ControlTuning{RampUp: 10, RampDown: 2})
+
+	assert.Equal(t, 25, tick(0).Duties["fan"])
+
+	sensor.temp = 60
+	assert.Equal(t, 35, tick(time.Second).Duties["fan"])
+	assert.Equal(t, 55, tick(2 * time.Second).Duties["fan"])
+	assert.Equal(t, 100, tick(10 * time.Second).Duties["fan"])
+
+	sensor.temp = 30
+	assert.Equal(t, 98, tick(time.Second).Duties["fan"])
+	assert.Equal(t, 78, tick(10 * time.Second).Duties["fan"])
+	assert.Equal(t, 25, tick(time.Minute).Duties["fan"])
+}
+
+func TestControllerFailSafeBypassesRamp(t *testing.T) {
+	sensor := &fakeSensor{temp: 30}
+	c, tick := newClockedController(t, sensor, ControlTuning{RampUp: 1, RampDown: 1})
+	tick(0)
+
+	sensor.err = errors.New("sensor gone")
+	_, err := c.Tick()
+	assert.Error(t, err)
+
+	sensor.err = nil
+	assert.Equal(t, 99, tick(time.Second).Duties["fan"])
+}
@@ -0,0 +1,183 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// curvePoints is the number of points the firmware stores per speed channel
+	curvePoints = 21
+
+	// maxCurveTemp is the highest temperature a point may have, the liquid never gets anywhere near it
+	maxCurveTemp = 100
+)
+
+// CurveConfig describes how speed profiles are mapped onto the points the device stores
+type CurveConfig struct {
+	CriticalTemp int `json:"critical_temp" yaml:"critical_temp"` // °C from which fan & pump always run at full speed
+	GridStart    int `json:"grid_start" yaml:"grid_start"`       // °C of the first point
+	GridStep     int `json:"grid_step" yaml:"grid_step"`         // °C between two points
+}
+
+// DefaultCurve is the curve configuration of liquidctl, used for all models unless configured otherwise
+var DefaultCurve = CurveConfig{CriticalTemp: criticalTemp, GridStart: 20, GridStep: 2}
+
+// CurveConfigs are the built-in curve configurations per device model, see ReadCurveConfigs to configure them
+var CurveConfigs = map[string]CurveConfig{
+	"x42": DefaultCurve,
+	"x52": DefaultCurve,
+	"x62": DefaultCurve,
+	"x72": DefaultCurve,
+}
+
+// Models lists the device models with a curve configuration, sorted by name
+func Models() []string {
+	var models []string
+	for model := range CurveConfigs {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+
+	return models
+}
+
+// curveConfigSection is a model's section of a curve configuration file, fields left out keep the built-in values
+type curveConfigSection struct {
+	CriticalTemp *int `json:"critical_temp" yaml:"critical_temp" toml:"critical_temp"`
+	GridStart    *int `json:"grid_start" yaml:"grid_start" toml:"grid_start"`
+	GridStep     *int `json:"grid_step" yaml:"grid_step" toml:"grid_step"`
+}
+
+// ReadCurveConfigs reads curve configurations per model in `format` (yaml, toml, json or empty to guess) from `r`,
+// on top of CurveConfigs, e.g. in YAML:
+//
+//	x62:
+//	  critical_temp: 55
+//	x72:
+//	  critical_temp: 50
+//	  grid_start: 10
+func ReadCurveConfigs(r io.Reader, format string) (map[string]CurveConfig, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == "" {
+		format = guessProfileFormat(b)
+	}
+
+	var raw map[string]curveConfigSection
+	switch format {
+	case "yaml":
+		err = yaml.UnmarshalStrict(b, &raw)
+	case "toml":
+		_, err = toml.Decode(string(b), &raw)
+	case "json":
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&raw)
+	default:
+		return nil, fmt.Errorf("%w: unknown file format %q", ErrInvalidCurve, format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCurve, err)
+	}
+
+	configs := map[string]CurveConfig{}
+	for model, section := range raw {
+		config, ok := CurveConfigs[strings.ToLower(model)]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown model %s (supported: %s)", ErrInvalidCurve, model, strings.Join(Models(), ", "))
+		}
+
+		if section.CriticalTemp != nil {
+			config.CriticalTemp = *section.CriticalTemp
+		}
+		if section.GridStart != nil {
+			config.GridStart = *section.GridStart
+		}
+		if section.GridStep != nil {
+			config.GridStep = *section.GridStep
+		}
+
+		if err := config.Validate(); err != nil {
+			return nil, fmt.Errorf("model %s: %w", model, err)
+		}
+		configs[strings.ToLower(model)] = config
+	}
+
+	return configs, nil
+}
+
+// GridEnd returns the temperature of the last point
+func (c CurveConfig) GridEnd() int {
+	return c.GridStart + (curvePoints-1)*c.GridStep
+}
+
+// Grid returns the temperatures of all points the device stores
+func (c CurveConfig) Grid() []int {
+	return makeRange(c.GridStart, c.GridEnd()+c.GridStep, c.GridStep)
+}
+
+// Validate refuses configurations the firmware can't store, the grid must fit 0-100 °C & contain the critical temperature
+func (c CurveConfig) Validate() error {
+	if c.GridStep < 1 {
+		return fmt.Errorf("%w: the grid step must be at least 1 °C, got %d", ErrInvalidCurve, c.GridStep)
+	}
+
+	if c.GridStart < 0 || c.GridEnd() > maxCurveTemp {
+		return fmt.Errorf("%w: the grid of %d points from %d °C in steps of %d °C ends at %d °C, beyond %d °C",
+			ErrInvalidCurve, curvePoints, c.GridStart, c.GridStep, c.GridEnd(), maxCurveTemp)
+	}
+
+	if c.CriticalTemp <= c.GridStart || c.CriticalTemp > c.GridEnd() {
+		return fmt.Errorf("%w: the critical temperature %d °C must be above %d °C & at most %d °C",
+			ErrInvalidCurve, c.CriticalTemp, c.GridStart, c.GridEnd())
+	}
+
+	return nil
+}
+
+// String describes the configuration, e.g. critical 60 °C, grid 20-60 °C in steps of 2 °C
+func (c CurveConfig) String() string {
+	return fmt.Sprintf("critical %d °C, grid %d-%d °C in steps of %d °C", c.CriticalTemp, c.GridStart, c.GridEnd(), c.GridStep)
+}
+
+// EffectiveProfile returns the points the device runs for `profile` on a speed channel, after normalizing, interpolating & clamping
+func (c CurveConfig) EffectiveProfile(channel string, profile SpeedProfile) (SpeedProfile, error) {
+	if _, ok := speedChannels[channel]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownChannel, channel)
+	}
+
+	if len(profile) == 0 {
+		return nil, fmt.Errorf("%w: empty profile", ErrInvalidProfile)
+	}
+
+	// normalizeProfile sorts in place, leave the caller's profile alone
+	normalized := normalizeProfile(append(SpeedProfile{}, profile...), c.CriticalTemp)
+
+	p := interpolateProfile(normalized, c.Grid())
+	for _, point := range p {
+		point[1] = clampDuty(channel, point[1])
+	}
+
+	return p, nil
+}
+
+// flatProfile returns a profile running at `duty` up to the critical temperature of `c`
+func flatProfile(c CurveConfig, duty int) SpeedProfile {
+	return SpeedProfile{{0, duty}, {c.CriticalTemp - 1, duty}, {c.CriticalTemp, 100}}
+}
@@ -0,0 +1,123 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCurveConfigGrid(t *testing.T) {
+	assert.Equal(t, makeRange(20, 62, 2), DefaultCurve.Grid())
+	assert.Equal(t, 60, DefaultCurve.GridEnd())
+
+	grid := CurveConfig{CriticalTemp: 45, GridStart: 25, GridStep: 1}.Grid()
+	assert.Len(t, grid, curvePoints)
+	assert.Equal(t, 25, grid[0])
+	assert.Equal(t, 45, grid[20])
+}
+
+var curveConfigValidateTests = []struct {
+	curve CurveConfig
+	valid bool
+}{
+	{DefaultCurve, true},
+	{CurveConfig{CriticalTemp: 50, GridStart: 20, GridStep: 2}, true},
+	{CurveConfig{CriticalTemp: 100, GridStart: 0, GridStep: 5}, true},
+	{CurveConfig{CriticalTemp: 45, GridStart: 25, GridStep: 1}, true},
+	{CurveConfig{CriticalTemp: 60, GridStart: 20, GridStep: 0}, false},
+	{CurveConfig{CriticalTemp: 60, GridStart: -2, GridStep: 2}, false},
+	{CurveConfig{CriticalTemp: 60, GridStart: 20, GridStep: 5}, false},
+	{CurveConfig{CriticalTemp: 70, GridStart: 20, GridStep: 2}, false},
+	{CurveConfig{CriticalTemp: 20, GridStart: 20, GridStep: 2}, false},
+}
+
+func TestCurveConfigValidate(t *testing.T) {
+	for _, tt := range curveConfigValidateTests {
+		err := tt.curve.Validate()
+
+		if tt.valid {
+			assert.Nil(t, err, "%s", tt.curve)
+		} else {
+			assert.True(t, errors.Is(err, ErrInvalidCurve), "%s", tt.curve)
+		}
+	}
+}
+
+func TestEffectiveProfileCriticalTemp(t *testing.T) {
+	curve := CurveConfig{CriticalTemp: 50, GridStart: 20, GridStep: 2}
+
+	p, err := curve.EffectiveProfile("fan", SpeedProfile{{20, 25}, {40, 50}})
+
+	require.Nil(t, err)
+	assert.Equal(t, []int{40, 50}, p[10])
+	assert.Equal(t, []int{50, 100}, p[15])
+	assert.Equal(t, []int{60, 100}, p[20])
+}
+
+func TestSetSpeedCurveConfig(t *testing.T) {
+	sim := NewSimulator()
+	kraken := NewKrakenDriverWithTransport(sim)
+	kraken.Curve = CurveConfig{CriticalTemp: 45, GridStart: 25, GridStep: 1}
+
+	require.Nil(t, kraken.SetSpeed("pump", "25 60  40 80"))
+
+	assert.Len(t, sim.Curves["pump"], curvePoints)
+	assert.Equal(t, []int{25, 60}, sim.Curves["pump"][0])
+	assert.Equal(t, []int{40, 80}, sim.Curves["pump"][15])
+	assert.Equal(t, []int{45, 100}, sim.Curves["pump"][20])
+}
+
+func TestValidateProfileCriticalTemp(t *testing.T) {
+	curve := CurveConfig{CriticalTemp: 50, GridStart: 20, GridStep: 2}
+
+	issues := curve.ValidateProfile("fan", SpeedProfile{{20, 25}, {55, 100}})
+
+	require.Len(t, issues, 1)
+	assert.Equal(t, "point 55 100: temperature outside the supported range 20-50 °C", issues[0].String())
+}
+
+var readCurveConfigsTests = []struct {
+	name, format, in string
+}{
+	{"yaml", "", "x62:\n  critical_temp: 55\nX72:\n  critical_temp: 50\n  grid_start: 10\n"},
+	{"toml", "toml", "[x62]\ncritical_temp = 55\n[X72]\ncritical_temp = 50\ngrid_start = 10\n"},
+	{"json", "json", `{"x62": {"critical_temp": 55}, "X72": {"critical_temp": 50, "grid_start": 10}}`},
+}
+
+func TestReadCurveConfigs(t *testing.T) {
+	for _, tt := range readCurveConfigsTests {
+		t.Run(tt.name, func(t *testing.T) {
+			configs, err := ReadCurveConfigs(strings.NewReader(tt.in), tt.format)
+
+			require.Nil(t, err)
+			assert.Equal(t, map[string]CurveConfig{
+				"x62": {CriticalTemp: 55, GridStart: 20, GridStep: 2},
+				"x72": {CriticalTemp: 50, GridStart: 10, GridStep: 2},
+			}, configs)
+		})
+	}
+}
+
+var readCurveConfigsInvalidTests = []string{
+	"x99:\n  critical_temp: 55\n",
+	"x62:\n  critical_temp: 70\n",
+	"x62:\n  critical: 55\n",
+	"x62: 55\n",
+}
+
+func TestReadCurveConfigsInvalid(t *testing.T) {
+	for _, in := range readCurveConfigsInvalidTests {
+		t.Run(in, func(t *testing.T) {
+			_, err := ReadCurveConfigs(strings.NewReader(in), "yaml")
+
+			assert.True(t, errors.Is(err, ErrInvalidCurve), "%v", err)
+		})
+	}
+}
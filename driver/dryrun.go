@@ -0,0 +1,168 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ErrDryRun is returned when reading from a DryRunTransport, nothing is read in a dry run
+var ErrDryRun = errors.New("dry run, the device is not read")
+
+// DryRunTransport is a Transport that prints every report written to it instead of sending it to a device
+type DryRunTransport struct {
+	w io.Writer
+}
+
+// NewDryRunTransport returns a DryRunTransport printing to `w`
+func NewDryRunTransport(w io.Writer) *DryRunTransport {
+	return &DryRunTransport{w: w}
+}
+
+// ReadContext always fails with ErrDryRun
+func (t *DryRunTransport) ReadContext(ctx context.Context, report []byte) (int, error) {
+	return 0, ErrDryRun
+}
+
+// Write prints `report` as hex, without its zero padding, followed by its decoded form
+func (t *DryRunTransport) Write(report []byte) (int, error) {
+	_, err := fmt.Fprintf(t.w, "write: % x\n       %s\n", bytes.TrimRight(report, "\x00"), DecodeReport(report))
+	if err != nil {
+		return 0, err
+	}
+
+	return len(report), nil
+}
+
+// Close does nothing
+func (t *DryRunTransport) Close() error {
+	return nil
+}
+
+// DecodeReport describes a color or speed report written to the device, e.g. fan curve point 3: 26°C → 25%
+func DecodeReport(report []byte) string {
+	if len(report) < 5 || report[0] != 0x2 {
+		return "unknown report"
+	}
+
+	switch report[1] {
+	case 0x4c:
+		return decodeColorReport(report)
+	case 0x4d:
+		return decodeSpeedReport(report)
+	}
+
+	return "unknown report"
+}
+
+// decodeColorReport describes a 0x2 0x4c lighting report, e.g. ring, mode fading, step 2, color #00FF00
+func decodeColorReport(report []byte) string {
+	if len(report) < 8+3*(totalLEDs-1) {
+		return "truncated color report"
+	}
+
+	channel := nameOf(colorChannels, int(report[2]&0x07))
+	speed := nameOf(animationSpeeds, int(report[4]&0x07))
+	step := int(report[4]>>5) + 1
+
+	// the logo color is sent as GRB, the ring leds as RGB
+	logo := fmt.Sprintf("#%02X%02X%02X", report[6], report[5], report[7])
+	var ring []string
+	for led := 0; led < totalLEDs-1; led++ {
+		i := 8 + 3*led
+		ring = append(ring, fmt.Sprintf("#%02X%02X%02X", report[i], report[i+1], report[i+2]))
+	}
+	uniform := allEqual(ring)
+
+	var colors string
+	switch {
+	case channel == "logo":
+		colors = "color " + logo
+	case channel == "ring" && uniform:
+		colors = "color " + ring[0]
+	case channel == "ring":
+		colors = "colors " + strings.Join(ring, " ")
+	case uniform && logo == ring[0]:
+		colors = "color " + logo
+	default:
+		colors = "logo " + logo + ", ring " + strings.Join(ring, " ")
+	}
+
+	mode := decodeColorMode(report[3], report[2]&^0x07, report[4]&0x18, channel, logo, ring)
+
+	return fmt.Sprintf("%s, mode %s, step %d, speed %s, %s", channel, mode, step, speed, colors)
+}
+
+// decodeColorMode returns the name of a color mode, telling modes that only differ in their colors apart by them
+func decodeColorMode(mval, mod2, mod4 byte, channel, logo string, ring []string) string {
+	var names []string
+	for name, mode := range colorModes {
+		if mode[0] == int(mval) && mode[1] == int(mod2) && mode[2] == int(mod4) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	independent := !allEqual(ring) || (channel == "sync" && logo != ring[0])
+	switch {
+	case len(names) == 0:
+		return fmt.Sprintf("unknown (%#02x)", mval)
+	case len(names) == 1:
+		return names[0]
+	case mval == 0x00 && !independent && logo == "#000000" && ring[0] == "#000000":
+		return "off"
+	}
+
+	// e.g. fixed & super-fixed, breathing & super-breathing
+	for _, name := range names {
+		if strings.HasPrefix(name, "super-") == independent && name != "off" {
+			return name
+		}
+	}
+
+	return strings.Join(names, " or ")
+}
+
+// decodeSpeedReport describes a 0x2 0x4d speed report, either a curve point or an instant duty
+func decodeSpeedReport(report []byte) string {
+	channel := "fan"
+	if report[2]&0x40 != 0 {
+		channel = "pump"
+	}
+
+	if report[2]&0x80 == 0 {
+		return fmt.Sprintf("%s duty: %d%% (instant)", channel, report[4])
+	}
+
+	return fmt.Sprintf("%s curve point %d: %d°C → %d%%", channel, report[2]&0x1f, report[3], report[4])
+}
+
+// nameOf returns the key of `value` in `m`, or the value in hex if there is none
+func nameOf(m map[string]int, value int) string {
+	for name, v := range m {
+		if v == value {
+			return name
+		}
+	}
+
+	return fmt.Sprintf("%#02x", value)
+}
+
+// allEqual checks if all strings in `s` are the same
+func allEqual(s []string) bool {
+	for _, v := range s[1:] {
+		if v != s[0] {
+			return false
+		}
+	}
+
+	return true
+}
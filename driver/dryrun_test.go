@@ -0,0 +1,99 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decodedWrites sets up a driver on a DryRunTransport, runs `set` & returns the decoded lines it printed
+func decodedWrites(t *testing.T, set func(*KrakenDriver) error) []string {
+	var out bytes.Buffer
+	require.Nil(t, set(NewKrakenDriverWithTransport(NewDryRunTransport(&out))))
+
+	var decoded []string
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if !strings.HasPrefix(line, "write: ") {
+			decoded = append(decoded, strings.TrimSpace(line))
+		}
+	}
+
+	return decoded
+}
+
+var decodeColorTests = []struct {
+	channel string
+	mode    string
+	colors  []string
+	decoded []string
+}{
+	{"ring", "fading", []string{"FF0000", "00FF00"}, []string{
+		"ring, mode fading, step 1, speed normal, color #FF0000",
+		"ring, mode fading, step 2, speed normal, color #00FF00",
+	}},
+	{"logo", "fixed", []string{"123456"}, []string{"logo, mode fixed, step 1, speed normal, color #123456"}},
+	{"sync", "off", nil, []string{"sync, mode off, step 1, speed normal, color #000000"}},
+	{"sync", "super-fixed", []string{"FF0000", "00FF00"}, []string{
+		"sync, mode super-fixed, step 1, speed normal, logo #FF0000, ring #00FF00 #000000 #000000 #000000 #000000 #000000 #000000 #000000",
+	}},
+	{"ring", "backwards-marquee-5", []string{"0000FF"}, []string{"ring, mode backwards-marquee-5, step 1, speed normal, color #0000FF"}},
+	{"ring", "breathing", []string{"FF0000"}, []string{"ring, mode breathing, step 1, speed normal, color #FF0000"}},
+}
+
+func TestDecodeColorReport(t *testing.T) {
+	for _, tt := range decodeColorTests {
+		t.Run(tt.channel+" "+tt.mode, func(t *testing.T) {
+			decoded := decodedWrites(t, func(d *KrakenDriver) error {
+				return d.SetColor(tt.channel, tt.mode, "normal", tt.colors)
+			})
+
+			assert.Equal(t, tt.decoded, decoded)
+		})
+	}
+}
+
+func TestDecodeSpeedReport(t *testing.T) {
+	decoded := decodedWrites(t, func(d *KrakenDriver) error {
+		return d.SetSpeed("fan", "20 25  35 25  50 55  60 100")
+	})
+
+	assert.Len(t, decoded, 21)
+	assert.Equal(t, "fan curve point 3: 26°C → 25%", decoded[3])
+	assert.Equal(t, "fan curve point 20: 60°C → 100%", decoded[20])
+
+	decoded = decodedWrites(t, func(d *KrakenDriver) error {
+		return d.SetInstantSpeed("pump", "70")
+	})
+
+	assert.Equal(t, []string{"pump duty: 70% (instant)"}, decoded)
+}
+
+func TestDecodeReportUnknown(t *testing.T) {
+	assert.Equal(t, "unknown report", DecodeReport([]byte{0x2, 0x4e, 0, 0, 0}))
+	assert.Equal(t, "unknown report", DecodeReport([]byte{0x2}))
+}
+
+func TestDryRunTransport(t *testing.T) {
+	var out bytes.Buffer
+	transport := NewDryRunTransport(&out)
+
+	_, err := transport.ReadContext(context.Background(), make([]byte, readLength))
+	assert.True(t, errors.Is(err, ErrDryRun))
+
+	report := make([]byte, writeLength)
+	copy(report, []byte{0x2, 0x4d, 0x40, 0x00, 0x46})
+	n, err := transport.Write(report)
+
+	assert.Nil(t, err)
+	assert.Equal(t, writeLength, n)
+	assert.Equal(t, "write: 02 4d 40 00 46\n       pump duty: 70% (instant)\n", out.String())
+}
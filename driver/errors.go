@@ -0,0 +1,45 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import "errors"
+
+var (
+	// ErrDeviceNotFound is returned when no matching device is connected
+	ErrDeviceNotFound = errors.New("NZXT Kraken X (X42, X52, X62 or X72) not found")
+
+	// ErrAmbiguousDevice is returned when a device selector matches more than one device
+	ErrAmbiguousDevice = errors.New("ambiguous device selection")
+
+	// ErrUnknownBackend is returned for backends not available in this build
+	ErrUnknownBackend = errors.New("unknown backend")
+
+	// ErrUnknownChannel is returned for color or speed channels the device does not have
+	ErrUnknownChannel = errors.New("unknown channel")
+
+	// ErrUnknownMode is returned for color modes the device does not know
+	ErrUnknownMode = errors.New("unknown mode")
+
+	// ErrUnsupportedMode is returned when a color mode can't be used with the requested channel
+	ErrUnsupportedMode = errors.New("unsupported mode")
+
+	// ErrUnknownSpeed is returned for animation speeds the device does not know
+	ErrUnknownSpeed = errors.New("unknown animation speed")
+
+	// ErrNotEnoughColors is returned when a color mode requires more colors than provided
+	ErrNotEnoughColors = errors.New("not enough colors")
+
+	// ErrInvalidColor is returned for colors that can't be parsed
+	ErrInvalidColor = errors.New("invalid color")
+
+	// ErrInvalidProfile is returned for speed profiles or duties that can't be parsed
+	ErrInvalidProfile = errors.New("invalid speed profile")
+
+	// ErrInvalidCurve is returned for curve configurations the firmware can't store
+	ErrInvalidCurve = errors.New("invalid curve configuration")
+
+	// ErrSensorNotFound is returned when no temperature sensor matches the requested name
+	ErrSensorNotFound = errors.New("temperature sensor not found")
+)
@@ -0,0 +1,276 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	// BlendRGB interpolates the red, green & blue components
+	BlendRGB = "rgb"
+	// BlendHSV interpolates hue, saturation & value, taking the shorter way around the hue circle
+	BlendHSV = "hsv"
+	// BlendOKLab interpolates in the perceptual OKLab space, keeping the brightness even
+	BlendOKLab = "oklab"
+)
+
+// colorGenerators are the generators ExpandColors knows, with the color space they blend in by default
+var colorGenerators = map[string]string{
+	"gradient":      BlendOKLab,
+	"rainbow":       BlendHSV,
+	"complementary": BlendOKLab,
+}
+
+// rgbf is a color with components in 0-1
+type rgbf [3]float64
+
+// ColorGenerator checks if `colors` start with a generator, e.g. gradient FF0000 0000FF or rainbow:oklab
+func ColorGenerator(colors []string) bool {
+	if len(colors) == 0 {
+		return false
+	}
+
+	name, _ := splitGenerator(colors[0])
+	_, ok := colorGenerators[name]
+
+	return ok
+}
+
+// ExpandColors returns `count` colors generated by the generator `colors` start with:
+//
+//	gradient C1 C2 [C3...]  blends from C1 over C2 to the last color
+//	rainbow                 runs once around the hue circle
+//	complementary C         blends from C to its complementary color
+//
+// The color space follows the name, e.g. gradient:hsv, see BlendRGB, BlendHSV & BlendOKLab.
+// Colors without a generator are returned as they are.
+func ExpandColors(colors []string, count int) ([]string, error) {
+	if !ColorGenerator(colors) {
+		return colors, nil
+	}
+
+	name, space := splitGenerator(colors[0])
+	if space == "" {
+		space = colorGenerators[name]
+	}
+	if space != BlendRGB && space != BlendHSV && space != BlendOKLab {
+		return nil, fmt.Errorf("%w %q: unknown color space %s (e.g: rgb, hsv or oklab)", ErrInvalidColor, colors[0], space)
+	}
+	if count < 1 {
+		count = 1
+	}
+
+	var stops []rgbf
+	for _, c := range colors[1:] {
+		parsed, err := ParseColor(c)
+		if err != nil {
+			return nil, err
+		}
+		stops = append(stops, rgbf{float64(parsed.R) / 255, float64(parsed.G) / 255, float64(parsed.B) / 255})
+	}
+
+	cyclic := false
+	switch {
+	case name == "gradient" && len(stops) < 2:
+		return nil, fmt.Errorf("%w for a gradient, at least 2 colors required", ErrNotEnoughColors)
+	case name == "rainbow" && len(stops) > 0:
+		return nil, fmt.Errorf("%w %q: a rainbow takes no colors", ErrInvalidColor, colors[1])
+	case name == "rainbow":
+		stops, cyclic = []rgbf{{1, 0, 0}, {1, 1, 0}, {0, 1, 0}, {0, 1, 1}, {0, 0, 1}, {1, 0, 1}, {1, 0, 0}}, true
+	case name == "complementary" && len(stops) != 1:
+		return nil, fmt.Errorf("%w for complementary colors, exactly 1 color required", ErrNotEnoughColors)
+	case name == "complementary":
+		h, s, v := rgbToHSV(stops[0])
+		stops = append(stops, hsvToRGB(math.Mod(h+180, 360), s, v))
+	}
+
+	expanded := make([]string, count)
+	for i := range expanded {
+		// gradients end at their last color, cycles just before they start over
+		t := 0.0
+		if cyclic {
+			t = float64(i) / float64(count)
+		} else if count > 1 {
+			t = float64(i) / float64(count-1)
+		}
+
+		c := blendStops(stops, t, space)
+		expanded[i] = fmt.Sprintf("%02X%02X%02X", toByte(c[0]), toByte(c[1]), toByte(c[2]))
+	}
+
+	return expanded, nil
+}
+
+// generateModeColors expands a generator in `colors` for `mode`: one color per ring LED in the super modes,
+// the logo taking the first one, otherwise as many colors as the mode takes
+func generateModeColors(colors []string, mode string, maxcolors, ringonly int) ([]string, error) {
+	if !ColorGenerator(colors) {
+		return colors, nil
+	}
+
+	super := strings.Contains(mode, "super")
+	count := maxcolors
+	if super {
+		count = ringLEDs
+	}
+
+	expanded, err := ExpandColors(colors, count)
+	if err != nil {
+		return nil, err
+	}
+	if super && ringonly == 0 {
+		expanded = append([]string{expanded[0]}, expanded...)
+	}
+
+	return expanded, nil
+}
+
+// splitGenerator splits gradient:hsv into the generator & its color space
+func splitGenerator(s string) (string, string) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if i := strings.Index(s, ":"); i >= 0 {
+		return s[:i], s[i+1:]
+	}
+
+	return s, ""
+}
+
+// blendStops returns the color at `t` in 0-1 along `stops`, evenly spaced
+func blendStops(stops []rgbf, t float64, space string) rgbf {
+	pos := t * float64(len(stops)-1)
+	i := int(math.Min(math.Floor(pos), float64(len(stops)-2)))
+
+	return blend(stops[i], stops[i+1], pos-float64(i), space)
+}
+
+// blend mixes `a` & `b` in `space`, `t` = 0 is `a` & `t` = 1 is `b`
+func blend(a, b rgbf, t float64, space string) rgbf {
+	switch space {
+	case BlendHSV:
+		ha, sa, va := rgbToHSV(a)
+		hb, sb, vb := rgbToHSV(b)
+		// grays have no hue, keep the other one
+		if sa == 0 {
+			ha = hb
+		} else if sb == 0 {
+			hb = ha
+		}
+		dh := math.Mod(hb-ha+540, 360) - 180
+
+		return hsvToRGB(math.Mod(ha+dh*t+360, 360), lerp(sa, sb, t), lerp(va, vb, t))
+	case BlendOKLab:
+		la, lb := rgbToOKLab(a), rgbToOKLab(b)
+
+		return okLabToRGB(rgbf{lerp(la[0], lb[0], t), lerp(la[1], lb[1], t), lerp(la[2], lb[2], t)})
+	}
+
+	return rgbf{lerp(a[0], b[0], t), lerp(a[1], b[1], t), lerp(a[2], b[2], t)}
+}
+
+// lerp interpolates linearly between `a` & `b`
+func lerp(a, b, t float64) float64 {
+	return a + (b-a)*t
+}
+
+// rgbToHSV converts to a hue in degrees, saturation & value in 0-1
+func rgbToHSV(c rgbf) (float64, float64, float64) {
+	max := math.Max(c[0], math.Max(c[1], c[2]))
+	min := math.Min(c[0], math.Min(c[1], c[2]))
+	d := max - min
+
+	var h float64
+	switch {
+	case d == 0:
+		h = 0
+	case max == c[0]:
+		h = 60 * math.Mod((c[1]-c[2])/d+6, 6)
+	case max == c[1]:
+		h = 60 * ((c[2]-c[0])/d + 2)
+	default:
+		h = 60 * ((c[0]-c[1])/d + 4)
+	}
+
+	s := 0.0
+	if max > 0 {
+		s = d / max
+	}
+
+	return h, s, max
+}
+
+// hsvToRGB converts a hue in degrees, saturation & value in 0-1
+func hsvToRGB(h, s, v float64) rgbf {
+	c := v * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := v - c
+
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+
+	return rgbf{r + m, g + m, b + m}
+}
+
+// rgbToOKLab converts from sRGB to OKLab, after Björn Ottosson
+func rgbToOKLab(c rgbf) rgbf {
+	r, g, b := srgbToLinear(c[0]), srgbToLinear(c[1]), srgbToLinear(c[2])
+
+	l := math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*b)
+	m := math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*b)
+	s := math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*b)
+
+	return rgbf{
+		0.2104542553*l + 0.7936177850*m - 0.0040720468*s,
+		1.9779984951*l - 2.4285922050*m + 0.4505937099*s,
+		0.0259040371*l + 0.7827717662*m - 0.8086757660*s,
+	}
+}
+
+// okLabToRGB converts from OKLab to sRGB, after Björn Ottosson
+func okLabToRGB(c rgbf) rgbf {
+	l := math.Pow(c[0]+0.3963377774*c[1]+0.2158037573*c[2], 3)
+	m := math.Pow(c[0]-0.1055613458*c[1]-0.0638541728*c[2], 3)
+	s := math.Pow(c[0]-0.0894841775*c[1]-1.2914855480*c[2], 3)
+
+	return rgbf{
+		linearToSRGB(4.0767416621*l - 3.3077115913*m + 0.2309699292*s),
+		linearToSRGB(-1.2684380046*l + 2.6097574011*m - 0.3413193965*s),
+		linearToSRGB(-0.0041960863*l - 0.7034186147*m + 1.7076147010*s),
+	}
+}
+
+// srgbToLinear removes the sRGB gamma
+func srgbToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// linearToSRGB applies the sRGB gamma, clamping to 0-1
+func linearToSRGB(v float64) float64 {
+	v = math.Max(0, math.Min(1, v))
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
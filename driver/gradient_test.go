@@ -0,0 +1,111 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var expandColorsTests = []struct {
+	colors []string
+	count  int
+	out    []string
+}{
+	{[]string{"FF0000", "00FF00"}, 8, []string{"FF0000", "00FF00"}},
+	{[]string{"gradient:rgb", "FF0000", "0000FF"}, 3, []string{"FF0000", "800080", "0000FF"}},
+	{[]string{"gradient:rgb", "red", "lime", "blue"}, 5, []string{"FF0000", "808000", "00FF00", "008080", "0000FF"}},
+	{[]string{"gradient:hsv", "red", "blue"}, 3, []string{"FF0000", "FF00FF", "0000FF"}},
+	{[]string{"gradient:hsv", "gray", "red"}, 2, []string{"808080", "FF0000"}},
+	{[]string{"gradient", "black", "white"}, 3, []string{"000000", "636363", "FFFFFF"}},
+	{[]string{"Gradient:OKLab", "red", "blue"}, 3, []string{"FF0000", "8C53A2", "0000FF"}},
+	{[]string{"gradient", "red", "blue"}, 1, []string{"FF0000"}},
+	{[]string{"rainbow"}, 6, []string{"FF0000", "FFFF00", "00FF00", "00FFFF", "0000FF", "FF00FF"}},
+	{[]string{"rainbow:rgb"}, 3, []string{"FF0000", "00FF00", "0000FF"}},
+	{[]string{"complementary:rgb", "orange"}, 2, []string{"FFA500", "005AFF"}},
+}
+
+func TestExpandColors(t *testing.T) {
+	for _, tt := range expandColorsTests {
+		t.Run(strings.Join(tt.colors, " "), func(t *testing.T) {
+			colors, err := ExpandColors(tt.colors, tt.count)
+
+			assert.Nil(t, err)
+			assert.Equal(t, tt.out, colors)
+		})
+	}
+}
+
+var expandColorsInvalidTests = []struct {
+	colors []string
+	err    error
+	msg    string
+}{
+	{[]string{"gradient", "red"}, ErrNotEnoughColors, "at least 2 colors required"},
+	{[]string{"gradient:lab", "red", "blue"}, ErrInvalidColor, "unknown color space lab"},
+	{[]string{"gradient", "red", "FF"}, ErrInvalidColor, `invalid color "FF"`},
+	{[]string{"rainbow", "red"}, ErrInvalidColor, "a rainbow takes no colors"},
+	{[]string{"complementary"}, ErrNotEnoughColors, "exactly 1 color required"},
+}
+
+func TestExpandColorsInvalid(t *testing.T) {
+	for _, tt := range expandColorsInvalidTests {
+		t.Run(strings.Join(tt.colors, " "), func(t *testing.T) {
+			colors, err := ExpandColors(tt.colors, 8)
+
+			assert.Nil(t, colors)
+			assert.True(t, errors.Is(err, tt.err))
+			assert.Contains(t, err.Error(), tt.msg)
+		})
+	}
+}
+
+var setColorGeneratorTests = []struct {
+	channel, mode string
+	colors        []string
+	decoded       []string
+}{
+	{"sync", "super-fixed", []string{"gradient:rgb", "FF0000", "0000FF"}, []string{
+		"sync, mode super-fixed, step 1, speed normal, logo #FF0000, ring #FF0000 #DB0024 #B60049 #92006D #6D0092 #4900B6 #2400DB #0000FF",
+	}},
+	{"ring", "super-wave", []string{"rainbow"}, []string{
+		"ring, mode super-wave, step 1, speed normal, colors #FF0000 #FFBF00 #80FF00 #00FF40 #00FFFF #0040FF #8000FF #FF00BF",
+	}},
+	{"logo", "fading", []string{"gradient:rgb", "000000", "FFFFFF"}, []string{
+		"logo, mode fading, step 1, speed normal, color #000000",
+		"logo, mode fading, step 2, speed normal, color #242424",
+		"logo, mode fading, step 3, speed normal, color #494949",
+		"logo, mode fading, step 4, speed normal, color #6D6D6D",
+		"logo, mode fading, step 5, speed normal, color #929292",
+		"logo, mode fading, step 6, speed normal, color #B6B6B6",
+		"logo, mode fading, step 7, speed normal, color #DBDBDB",
+		"logo, mode fading, step 8, speed normal, color #FFFFFF",
+	}},
+}
+
+func TestSetColorGenerators(t *testing.T) {
+	for _, tt := range setColorGeneratorTests {
+		t.Run(tt.mode+" "+strings.Join(tt.colors, " "), func(t *testing.T) {
+			decoded := decodedWrites(t, func(d *KrakenDriver) error {
+				return d.SetColor(tt.channel, tt.mode, "normal", tt.colors)
+			})
+
+			assert.Equal(t, tt.decoded, decoded)
+		})
+	}
+}
+
+func TestSetColorGeneratorState(t *testing.T) {
+	kraken := NewKrakenDriverWithTransport(NewMemoryTransport())
+	kraken.State = &DeviceState{}
+
+	require.Nil(t, kraken.SetColor("ring", "super-wave", "normal", []string{"rainbow"}))
+
+	assert.Equal(t, []string{"FF0000", "FFBF00", "80FF00", "00FF40", "00FFFF", "0040FF", "8000FF", "FF00BF"}, kraken.State.Color["ring"].Colors)
+}
@@ -0,0 +1,172 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+//go:build linux
+// +build linux
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	sysfsRoot = "/sys"
+	devRoot   = "/dev"
+)
+
+func init() {
+	backends["hidraw"] = hidrawBackend{}
+}
+
+// hidrawBackend opens devices through the kernel hidraw interface, leaving the HID driver bound
+type hidrawBackend struct{}
+
+// Devices lists all hidraw devices matching `vid` & `pid`
+func (hidrawBackend) Devices(vid, pid uint16) ([]DeviceInfo, error) {
+	class := filepath.Join(sysfsRoot, "class", "hidraw")
+	entries, err := ioutil.ReadDir(class)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var infos []DeviceInfo
+	for _, entry := range entries {
+		device := filepath.Join(class, entry.Name(), "device")
+
+		devVid, devPid, err := readHidID(filepath.Join(device, "uevent"))
+		if err != nil || devVid != vid || devPid != pid {
+			continue
+		}
+
+		infos = append(infos, hidrawDeviceInfo(device, filepath.Join(devRoot, entry.Name())))
+	}
+
+	return infos, nil
+}
+
+// Open opens the device node of the device described by `info`
+func (hidrawBackend) Open(info DeviceInfo) (Transport, error) {
+	return openHidraw(info.Path)
+}
+
+// hidrawDeviceInfo describes the HID device at `device`, reading the USB attributes two levels up
+func hidrawDeviceInfo(device, node string) DeviceInfo {
+	info := DeviceInfo{Backend: "hidraw", Path: node}
+
+	// <usb device>/<usb interface>/<hid device>
+	hid, err := filepath.EvalSymlinks(device)
+	if err != nil {
+		return info
+	}
+	usb := filepath.Dir(filepath.Dir(hid))
+
+	info.Bus, _ = strconv.Atoi(readSysfsAttr(usb, "busnum"))
+	info.PortPath = readSysfsAttr(usb, "devpath")
+	info.Address, _ = strconv.Atoi(readSysfsAttr(usb, "devnum"))
+	info.Manufacturer = readSysfsAttr(usb, "manufacturer")
+	info.Product = readSysfsAttr(usb, "product")
+	info.SerialNumber = readSysfsAttr(usb, "serial")
+
+	return info
+}
+
+// hidrawTransport talks to the device through a /dev/hidrawN node
+type hidrawTransport struct {
+	file *os.File
+}
+
+// openHidraw opens the hidraw device node at `node`
+func openHidraw(node string) (*hidrawTransport, error) {
+	file, err := os.OpenFile(node, os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s failed: %w", node, err)
+	}
+
+	return &hidrawTransport{file: file}, nil
+}
+
+// readHidID reads the vendor & product IDs from the HID_ID line of a uevent file
+func readHidID(path string) (uint16, uint16, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "HID_ID=") {
+			continue
+		}
+
+		// HID_ID=<bus>:<vendor>:<product>, e.g. HID_ID=0003:00001E71:0000170E
+		id := strings.Split(strings.TrimPrefix(line, "HID_ID="), ":")
+		if len(id) != 3 {
+			return 0, 0, fmt.Errorf("malformed HID_ID in %s: %s", path, line)
+		}
+
+		vid, err := strconv.ParseUint(id[1], 16, 16)
+		if err != nil {
+			return 0, 0, fmt.Errorf("malformed HID_ID in %s: %w", path, err)
+		}
+
+		pid, err := strconv.ParseUint(id[2], 16, 16)
+		if err != nil {
+			return 0, 0, fmt.Errorf("malformed HID_ID in %s: %w", path, err)
+		}
+
+		return uint16(vid), uint16(pid), nil
+	}
+
+	if err := scanner.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	return 0, 0, fmt.Errorf("no HID_ID in %s", path)
+}
+
+// ReadContext reads a single report, giving up once `ctx` is done
+func (t *hidrawTransport) ReadContext(ctx context.Context, report []byte) (int, error) {
+	deadline, _ := ctx.Deadline()
+	t.file.SetReadDeadline(deadline)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			t.file.SetReadDeadline(time.Now())
+		case <-done:
+		}
+	}()
+
+	n, err := t.file.Read(report)
+	if err != nil && ctx.Err() != nil {
+		return n, ctx.Err()
+	}
+
+	return n, err
+}
+
+// Write writes a single report, the first byte being the report ID
+func (t *hidrawTransport) Write(report []byte) (int, error) {
+	return t.file.Write(report)
+}
+
+// Close closes the device node
+func (t *hidrawTransport) Close() error {
+	return t.file.Close()
+}
@@ -0,0 +1,201 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+//go:build linux
+// +build linux
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDevice describes a device in a fake sysfs tree
+type fakeDevice struct {
+	uevent  string
+	devpath string
+	serial  string
+}
+
+const (
+	keyboardUevent = "DRIVER=hid-generic\nHID_ID=0003:0000046D:0000C31C\nHID_NAME=Logitech USB Keyboard\n"
+	krakenUevent   = "DRIVER=hid-generic\nHID_ID=0003:00001E71:0000170E\nHID_NAME=NZXT.-Inc. NZXT USB Device\n"
+)
+
+// fakeHidraw creates a fake sysfs tree with one hidraw device per `devices` & a FIFO standing in for each device node
+func fakeHidraw(t *testing.T, devices ...fakeDevice) func() {
+	root, err := ioutil.TempDir("", "coolctl-hidraw")
+	require.Nil(t, err)
+
+	oldSysfsRoot, oldDevRoot := sysfsRoot, devRoot
+	sysfsRoot, devRoot = filepath.Join(root, "sys"), filepath.Join(root, "dev")
+	require.Nil(t, os.MkdirAll(devRoot, 0755))
+	require.Nil(t, os.MkdirAll(filepath.Join(sysfsRoot, "class", "hidraw"), 0755))
+
+	for i, device := range devices {
+		name := "hidraw" + strconv.Itoa(i)
+
+		usb := filepath.Join(sysfsRoot, "devices", "usb1", "1-"+device.devpath)
+		hid := filepath.Join(usb, "1-"+device.devpath+":1.0", "0003:1E71:170E.000"+strconv.Itoa(i))
+		require.Nil(t, os.MkdirAll(hid, 0755))
+		require.Nil(t, ioutil.WriteFile(filepath.Join(hid, "uevent"), []byte(device.uevent), 0644))
+
+		attrs := map[string]string{
+			"busnum":       "1",
+			"devnum":       strconv.Itoa(i + 2),
+			"devpath":      device.devpath,
+			"manufacturer": "NZXT.-Inc.",
+			"product":      "NZXT USB Device",
+			"serial":       device.serial,
+		}
+		for attr, value := range attrs {
+			require.Nil(t, ioutil.WriteFile(filepath.Join(usb, attr), []byte(value+"\n"), 0644))
+		}
+
+		class := filepath.Join(sysfsRoot, "class", "hidraw", name)
+		require.Nil(t, os.MkdirAll(class, 0755))
+		require.Nil(t, os.Symlink(hid, filepath.Join(class, "device")))
+		require.Nil(t, syscall.Mkfifo(filepath.Join(devRoot, name), 0600))
+	}
+
+	return func() {
+		sysfsRoot, devRoot = oldSysfsRoot, oldDevRoot
+		os.RemoveAll(root)
+	}
+}
+
+func TestHidrawDevices(t *testing.T) {
+	defer fakeHidraw(t,
+		fakeDevice{keyboardUevent, "1", "KBD"},
+		fakeDevice{"DRIVER=hid-generic\n", "2", ""},
+		fakeDevice{krakenUevent, "3.4", "61A4A2C3B052"},
+	)()
+
+	devices, err := hidrawBackend{}.Devices(vendorID, productID)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []DeviceInfo{{
+		Backend:      "hidraw",
+		Bus:          1,
+		PortPath:     "3.4",
+		Address:      4,
+		Manufacturer: "NZXT.-Inc.",
+		Product:      "NZXT USB Device",
+		SerialNumber: "61A4A2C3B052",
+		Path:         filepath.Join(devRoot, "hidraw2"),
+	}}, devices)
+}
+
+func TestHidrawDevicesNotFound(t *testing.T) {
+	defer fakeHidraw(t, fakeDevice{keyboardUevent, "1", "KBD"})()
+
+	devices, err := hidrawBackend{}.Devices(vendorID, productID)
+
+	assert.Nil(t, err)
+	assert.Empty(t, devices)
+}
+
+func TestHidrawDevicesNoSysfs(t *testing.T) {
+	defer fakeHidraw(t)()
+	os.RemoveAll(sysfsRoot)
+
+	devices, err := hidrawBackend{}.Devices(vendorID, productID)
+
+	assert.Nil(t, err)
+	assert.Empty(t, devices)
+}
+
+func TestReadHidIDMalformed(t *testing.T) {
+	file, err := ioutil.TempFile("", "uevent")
+	require.Nil(t, err)
+	defer os.Remove(file.Name())
+	file.WriteString("HID_ID=0003:zzzz:170E\n")
+	file.Close()
+
+	_, _, err = readHidID(file.Name())
+
+	assert.Error(t, err)
+}
+
+// openFakeKraken opens the only Kraken in a fake sysfs tree through the hidraw backend
+func openFakeKraken(t *testing.T) Transport {
+	devices, err := hidrawBackend{}.Devices(vendorID, productID)
+	require.Nil(t, err)
+	require.Len(t, devices, 1)
+
+	transport, err := hidrawBackend{}.Open(devices[0])
+	require.Nil(t, err)
+
+	return transport
+}
+
+func TestHidrawTransport(t *testing.T) {
+	defer fakeHidraw(t, fakeDevice{krakenUevent, "1", "1"})()
+
+	transport := openFakeKraken(t)
+	defer transport.Close()
+
+	report := make([]byte, writeLength)
+	report[0], report[1] = 0x2, 0x4d
+	n, err := transport.Write(report)
+	assert.Nil(t, err)
+	assert.Equal(t, writeLength, n)
+
+	msg := make([]byte, readLength)
+	n, err = transport.ReadContext(context.Background(), msg)
+	assert.Nil(t, err)
+	assert.Equal(t, readLength, n)
+	assert.Equal(t, report[:readLength], msg)
+}
+
+func TestHidrawTransportReadTimeout(t *testing.T) {
+	defer fakeHidraw(t, fakeDevice{krakenUevent, "1", "1"})()
+
+	transport := openFakeKraken(t)
+	defer transport.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := transport.ReadContext(ctx, make([]byte, readLength))
+
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestConnectHidraw(t *testing.T) {
+	defer fakeHidraw(t, fakeDevice{krakenUevent, "1", "61A4A2C3B052"})()
+
+	kraken := NewKrakenDriver()
+	kraken.Backend = "hidraw"
+	require.Nil(t, kraken.Connect())
+	defer kraken.Close()
+
+	_, err := kraken.Transport.Write(statusReport())
+	require.Nil(t, err)
+
+	status, err := kraken.GetStatus()
+
+	assert.Nil(t, err)
+	assert.Equal(t, FirmwareVersion{6, 0, 2}, status.FirmwareVersion)
+	assert.Equal(t, "61A4A2C3B052", kraken.Device.SerialNumber)
+}
+
+func TestConnectHidrawNotFound(t *testing.T) {
+	defer fakeHidraw(t, fakeDevice{keyboardUevent, "1", "KBD"})()
+
+	kraken := NewKrakenDriver()
+	kraken.Backend = "hidraw"
+
+	assert.Equal(t, ErrDeviceNotFound, kraken.Connect())
+}
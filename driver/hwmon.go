@@ -0,0 +1,126 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// HwmonSensor is a temperature input of a Linux hwmon device, e.g. the CPU package temperature
+type HwmonSensor struct {
+	Device string // e.g. hwmon1
+	Chip   string // e.g. k10temp or coretemp
+	Input  string // e.g. temp1
+	Label  string // e.g. Tctl or Package id 0, the input if the chip has no labels
+	Path   string // e.g. /sys/class/hwmon/hwmon1/temp1_input
+}
+
+// String returns the name the sensor is selected by, e.g. coretemp/Package id 0
+func (s HwmonSensor) String() string {
+	return s.Chip + "/" + s.Label
+}
+
+// ReadTemperature reads the current temperature in °C
+func (s HwmonSensor) ReadTemperature() (float64, error) {
+	b, err := ioutil.ReadFile(s.Path)
+	if err != nil {
+		return 0, err
+	}
+
+	// millidegree Celsius
+	milli, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil {
+		return 0, fmt.Errorf("malformed temperature in %s: %w", s.Path, err)
+	}
+
+	return float64(milli) / 1000, nil
+}
+
+// HwmonSensors lists all temperature inputs below `<root>/class/hwmon`, root being the sysfs mount point
+func HwmonSensors(root string) ([]HwmonSensor, error) {
+	inputs, err := filepath.Glob(filepath.Join(root, "class", "hwmon", "*", "temp*_input"))
+	if err != nil {
+		return nil, err
+	}
+
+	var sensors []HwmonSensor
+	for _, path := range inputs {
+		dir := filepath.Dir(path)
+		input := strings.TrimSuffix(filepath.Base(path), "_input")
+
+		sensor := HwmonSensor{
+			Device: filepath.Base(dir),
+			Chip:   readSysfsAttr(dir, "name"),
+			Input:  input,
+			Label:  readSysfsAttr(dir, input+"_label"),
+			Path:   path,
+		}
+		if sensor.Chip == "" {
+			sensor.Chip = sensor.Device
+		}
+		if sensor.Label == "" {
+			sensor.Label = input
+		}
+
+		sensors = append(sensors, sensor)
+	}
+
+	// hwmon2 before hwmon10, temp2 before temp10
+	sort.SliceStable(sensors, func(i, j int) bool {
+		if sensors[i].Device != sensors[j].Device {
+			return naturalLess(sensors[i].Device, sensors[j].Device)
+		}
+		return naturalLess(sensors[i].Input, sensors[j].Input)
+	})
+
+	return sensors, nil
+}
+
+// FindHwmonSensor returns the sensor below `root` named `name`, which is either <chip>/<label>, <chip>/<input>,
+// <device>/<input> or a chip alone for its first input, e.g. coretemp/Package id 0, k10temp or hwmon1/temp1
+func FindHwmonSensor(root, name string) (HwmonSensor, error) {
+	sensors, err := HwmonSensors(root)
+	if err != nil {
+		return HwmonSensor{}, err
+	}
+
+	for _, s := range sensors {
+		for _, candidate := range []string{s.String(), s.Chip + "/" + s.Input, s.Device + "/" + s.Input, s.Chip} {
+			if strings.EqualFold(candidate, name) {
+				return s, nil
+			}
+		}
+	}
+
+	return HwmonSensor{}, fmt.Errorf("%w: %s", ErrSensorNotFound, name)
+}
+
+// readSysfsAttr returns the trimmed content of the sysfs attribute `name` in `dir`, or an empty string
+func readSysfsAttr(dir, name string) string {
+	b, err := ioutil.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(b))
+}
+
+// naturalLess compares names ending in a number, e.g. temp2 < temp10
+func naturalLess(a, b string) bool {
+	na, nb := strings.TrimRight(a, "0123456789"), strings.TrimRight(b, "0123456789")
+	if na != nb {
+		return a < b
+	}
+
+	ia, _ := strconv.Atoi(a[len(na):])
+	ib, _ := strconv.Atoi(b[len(nb):])
+
+	return ia < ib
+}
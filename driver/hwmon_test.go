@@ -0,0 +1,120 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeHwmon creates a fake sysfs tree with the hwmon attributes in `devices`, keyed by device & attribute name
+func fakeHwmon(t *testing.T, devices map[string]map[string]string) (string, func()) {
+	root, err := ioutil.TempDir("", "coolctl-hwmon")
+	require.Nil(t, err)
+
+	for device, attrs := range devices {
+		dir := filepath.Join(root, "class", "hwmon", device)
+		require.Nil(t, os.MkdirAll(dir, 0755))
+
+		for attr, value := range attrs {
+			require.Nil(t, ioutil.WriteFile(filepath.Join(dir, attr), []byte(value+"\n"), 0644))
+		}
+	}
+
+	return root, func() { os.RemoveAll(root) }
+}
+
+var hwmonDevices = map[string]map[string]string{
+	"hwmon0":  {"name": "acpitz", "temp1_input": "27800"},
+	"hwmon10": {"name": "nvme", "temp1_input": "38850", "temp1_label": "Composite"},
+	"hwmon2": {
+		"name":         "coretemp",
+		"temp1_input":  "54000",
+		"temp1_label":  "Package id 0",
+		"temp10_input": "51000",
+		"temp10_label": "Core 8",
+		"temp2_input":  "50000",
+		"temp2_label":  "Core 0",
+	},
+	"hwmon3": {"temp1_input": "40000"},
+}
+
+func TestHwmonSensors(t *testing.T) {
+	root, cleanup := fakeHwmon(t, hwmonDevices)
+	defer cleanup()
+
+	sensors, err := HwmonSensors(root)
+	require.Nil(t, err)
+
+	var names []string
+	for _, s := range sensors {
+		names = append(names, s.String())
+	}
+	assert.Equal(t, []string{
+		"acpitz/temp1",
+		"coretemp/Package id 0",
+		"coretemp/Core 0",
+		"coretemp/Core 8",
+		"hwmon3/temp1",
+		"nvme/Composite",
+	}, names)
+}
+
+var findHwmonSensorTests = []struct {
+	name string
+	path string
+}{
+	{"coretemp/Package id 0", "hwmon2/temp1_input"},
+	{"CORETEMP/core 8", "hwmon2/temp10_input"},
+	{"coretemp", "hwmon2/temp1_input"},
+	{"coretemp/temp2", "hwmon2/temp2_input"},
+	{"hwmon10/temp1", "hwmon10/temp1_input"},
+	{"nvme", "hwmon10/temp1_input"},
+}
+
+func TestFindHwmonSensor(t *testing.T) {
+	root, cleanup := fakeHwmon(t, hwmonDevices)
+	defer cleanup()
+
+	for _, tt := range findHwmonSensorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			sensor, err := FindHwmonSensor(root, tt.name)
+
+			require.Nil(t, err)
+			assert.Equal(t, filepath.Join(root, "class", "hwmon", tt.path), sensor.Path)
+		})
+	}
+}
+
+func TestFindHwmonSensorNotFound(t *testing.T) {
+	root, cleanup := fakeHwmon(t, hwmonDevices)
+	defer cleanup()
+
+	_, err := FindHwmonSensor(root, "k10temp")
+
+	assert.True(t, errors.Is(err, ErrSensorNotFound))
+}
+
+func TestHwmonSensorReadTemperature(t *testing.T) {
+	root, cleanup := fakeHwmon(t, hwmonDevices)
+	defer cleanup()
+
+	sensor, err := FindHwmonSensor(root, "nvme")
+	require.Nil(t, err)
+
+	temp, err := sensor.ReadTemperature()
+	assert.Nil(t, err)
+	assert.Equal(t, 38.85, temp)
+
+	require.Nil(t, ioutil.WriteFile(sensor.Path, []byte("hot\n"), 0644))
+	_, err = sensor.ReadTemperature()
+	assert.Error(t, err)
+}
@@ -11,10 +11,10 @@ package driver
 import (
 	"context"
 	"fmt"
+	"image/color"
 	"strconv"
 	"time"
 
-	"github.com/google/gousb"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -22,10 +22,8 @@ const (
 	productID = 0x170e // Kraken X (X42, X52, X62 or X72)
 	vendorID  = 0x1e71 // NZXT
 
-	readEndpoint  = 1
-	readLength    = 64
-	writeEndpoint = 1
-	writeLength   = 65
+	readLength  = 64
+	writeLength = 65
 
 	totalLEDs    = 9
 	criticalTemp = 60
@@ -35,11 +33,7 @@ var (
 	// Debug is the debug level, 0 = no output, 10 = more output
 	Debug int
 
-	config    = 1
-	iface     = 0
-	alternate = 0
-	bufSize   = 0
-	timeout   = time.Duration(0)
+	timeout = time.Duration(0)
 
 	speedChannels = map[string][]int{
 		"fan":  {0x80, 25, 100},
@@ -93,104 +87,172 @@ var (
 	}
 )
 
-type contextReader interface {
-	ReadContext(context.Context, []byte) (int, error)
+// ValidSpeed checks if `speed` is an animation speed the device knows
+func ValidSpeed(speed string) error {
+	if _, ok := animationSpeeds[speed]; !ok {
+		return fmt.Errorf("%w: %s (e.g: slowest, slower, normal, faster or fastest)", ErrUnknownSpeed, speed)
+	}
+
+	return nil
+}
+
+// AnimatedColorMode checks if `mode` is animated, the animation speed has no effect on modes that are not
+func AnimatedColorMode(mode string) bool {
+	switch mode {
+	case "off", "fixed", "super-fixed":
+		return false
+	}
+
+	return true
 }
 
 // KrakenDriver holds all driver relevant informations
 type KrakenDriver struct {
-	ProductID       gousb.ID
-	VendorID        gousb.ID
-	FirmwareVersion []int
+	ProductID       uint16
+	VendorID        uint16
+	Backend         string
+	Selector        DeviceSelector
+	Device          DeviceInfo
+	FirmwareVersion FirmwareVersion
 	CoolingProfiles bool
-	*gousb.Context
-	*gousb.Interface
-	*gousb.InEndpoint
-	*gousb.OutEndpoint
+	StrictProfiles  bool // reject speed profiles that would be clamped or rewritten, see CurveConfig.ValidateProfile
+	Curve           CurveConfig
+	State           *DeviceState // remembers every speed & color applied, if set
+	Transport
 }
 
-// NewKrakenDriver creates a new USB Context instance & returns a new KrakenDriver
+// NewKrakenDriver returns a new KrakenDriver, call Connect to attach it to the device
 func NewKrakenDriver() *KrakenDriver {
-	ctx := gousb.NewContext()
-	ctx.Debug(Debug)
 	log.SetLevel(log.Level(Debug))
 
 	return &KrakenDriver{
 		ProductID: productID,
 		VendorID:  vendorID,
-		Context:   ctx,
+		Backend:   DefaultBackend,
+		Selector:  AnyDevice,
+		Curve:     DefaultCurve,
 	}
 }
 
-// Connect connects to the USB device
-func (d *KrakenDriver) Connect() {
-	dev, err := d.Context.OpenDeviceWithVIDPID(d.VendorID, d.ProductID)
-	if err != nil {
-		log.Fatal("NZXT Kraken X (X42, X52, X62 or X72) not found")
-	}
-	defer dev.Close()
+// NewKrakenDriverWithTransport returns a new KrakenDriver already attached to `t`
+func NewKrakenDriverWithTransport(t Transport) *KrakenDriver {
+	d := NewKrakenDriver()
+	d.Transport = t
 
-	err = dev.SetAutoDetach(true)
+	return d
+}
+
+// Devices lists all matching devices visible to the configured backend
+func (d *KrakenDriver) Devices() ([]DeviceInfo, error) {
+	backend, err := lookupBackend(d.Backend)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
-	cfg, err := dev.Config(config)
+	return backend.Devices(d.VendorID, d.ProductID)
+}
+
+// Connect connects to the device picked by the selector through the configured backend
+func (d *KrakenDriver) Connect() error {
+	devices, err := d.Devices()
 	if err != nil {
-		log.Fatalf("dev.Config(%d): %v", config, err)
+		return err
 	}
 
-	d.Interface, err = cfg.Interface(iface, alternate)
+	device, err := d.Selector.Select(devices)
 	if err != nil {
-		log.Fatalf("cfg.Interface(%d, %d): %v", iface, alternate, err)
+		return err
 	}
 
-	d.InEndpoint, err = d.Interface.InEndpoint(readEndpoint)
+	return d.ConnectDevice(device)
+}
+
+// ConnectDevice connects to the device described by `info`
+func (d *KrakenDriver) ConnectDevice(info DeviceInfo) error {
+	backend, err := lookupBackend(info.Backend)
 	if err != nil {
-		log.Fatalf("dev.InEndpoint(): %s", err)
+		return err
 	}
 
-	d.OutEndpoint, err = d.Interface.OutEndpoint(writeEndpoint)
+	t, err := backend.Open(info)
 	if err != nil {
-		log.Fatalf("dev.OutEndpoint(): %s", err)
+		return err
 	}
+	d.Transport, d.Device = t, info
+
+	return nil
 }
 
 // GetStatus reads & returns the current device status
-func (d *KrakenDriver) GetStatus() (string, uint64, uint64, string) {
-	msg := d.read()
+func (d *KrakenDriver) GetStatus() (Status, error) {
+	return d.GetStatusContext(context.Background())
+}
 
-	temperature := fmt.Sprintf("%d.%d", uint64(msg[1]), uint64(msg[2]))
-	fanSpeed := uint64(msg[3])<<8 | uint64(msg[4])
-	pumpSpeed := uint64(msg[5])<<8 | uint64(msg[6])
-	firmwareVersion := d.readFirmwareVersion(msg)
+// GetStatusContext reads & returns the current device status, giving up once `ctx` is done
+func (d *KrakenDriver) GetStatusContext(ctx context.Context) (Status, error) {
+	msg, err := d.read(ctx)
+	if err != nil {
+		return Status{}, err
+	}
 
-	return temperature, fanSpeed, pumpSpeed, firmwareVersion
+	return Status{
+		LiquidTemperature: float64(msg[1]) + float64(msg[2])/10,
+		FanSpeed:          int(msg[3])<<8 | int(msg[4]),
+		PumpSpeed:         int(msg[5])<<8 | int(msg[6]),
+		FirmwareVersion:   d.readFirmwareVersion(msg),
+		Time:              time.Now(),
+	}, nil
 }
 
-// SetColor sets the color of a channel & mode
-func (d *KrakenDriver) SetColor(channel, mode string, colors []string) {
+// SetColor sets the color of a channel & mode, animated at `speed` (slowest, slower, normal, faster or fastest)
+func (d *KrakenDriver) SetColor(channel, mode, speed string, colors []string) error {
 	colorChannel, ok := colorChannels[channel]
 	if !ok {
-		log.Fatalf("channel %s not found", channel)
+		return fmt.Errorf("%w: %s", ErrUnknownChannel, channel)
 	}
 
 	colorMode, ok := colorModes[mode]
 	if !ok {
-		log.Fatalf("mode %s not found", mode)
+		return fmt.Errorf("%w: %s", ErrUnknownMode, mode)
+	}
+
+	if err := ValidSpeed(speed); err != nil {
+		return err
 	}
+	animationSpeed := animationSpeeds[speed]
 
-	mval, mod2, mod4, mincolors, maxcolors, ringonly := colorMode[0], colorMode[1], colorMode[2], colorMode[3], colorMode[4], colorMode[5]
+	mincolors, maxcolors, ringonly := colorMode[3], colorMode[4], colorMode[5]
 	if ringonly == 1 && channel != "ring" {
-		log.Fatalf("mode %s unsupported with channel %s", mode, channel)
+		return fmt.Errorf("%w: %s with channel %s", ErrUnsupportedMode, mode, channel)
+	}
+
+	colors, err := generateModeColors(colors, mode, maxcolors, ringonly)
+	if err != nil {
+		return err
 	}
 
 	palette, err := paletteFromColors(colors)
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+
+	steps, err := generateSteps(*palette, mincolors, maxcolors, mode, ringonly)
+	if err != nil {
+		return err
+	}
+
+	if err := d.writeColorSteps(colorChannel, colorMode, animationSpeed, steps); err != nil {
+		return err
 	}
+	d.State.recordColor(channel, mode, speed, colors)
+
+	return nil
+}
+
+// writeColorSteps writes a lighting report per step of `colorMode` to `colorChannel`
+func (d *KrakenDriver) writeColorSteps(colorChannel int, colorMode []int, animationSpeed int, steps []color.Palette) error {
+	mval, mod2, mod4 := colorMode[0], colorMode[1], colorMode[2]
 
-	steps := generateSteps(*palette, mincolors, maxcolors, mode, ringonly)
 	for seq, step := range steps {
 		logoRed, logoGreen, logoBlue, _ := step[0].RGBA()
 
@@ -199,7 +261,7 @@ func (d *KrakenDriver) SetColor(channel, mode string, colors []string) {
 			0x4c,
 			byte(mod2 | colorChannel),
 			byte(mval),
-			byte(animationSpeeds["normal"] | seq<<5 | mod4),
+			byte(animationSpeed | seq<<5 | mod4),
 			byte(logoGreen),
 			byte(logoRed),
 			byte(logoBlue),
@@ -211,120 +273,198 @@ func (d *KrakenDriver) SetColor(channel, mode string, colors []string) {
 			buf = append(buf, colors...)
 		}
 
-		d.write(buf)
+		if err := d.write(buf); err != nil {
+			return err
+		}
 	}
+
+	return nil
 }
 
 // SetSpeed sets a profile for a speed channel
-func (d *KrakenDriver) SetSpeed(channel, profile string) {
-	speedChannel, ok := speedChannels[channel]
-	if !ok {
-		log.Fatalf("channel %s not found", channel)
+func (d *KrakenDriver) SetSpeed(channel, profile string) error {
+	if _, ok := speedChannels[channel]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownChannel, channel)
 	}
 
-	cbase, dmin, dmax, p := speedChannel[0], speedChannel[1], speedChannel[2], interpolateProfile(normalizeProfile(parseProfile(profile), criticalTemp))
-	log.Infof("setting profile for channel '%s': %v", channel, p)
+	parsed, err := parseProfile(profile)
+	if err != nil {
+		return err
+	}
 
-	for i, profile := range p {
-		duty := profile[1]
+	return d.SetSpeedProfile(channel, parsed)
+}
 
-		if duty < dmin {
-			duty = dmin
-		} else if duty > dmax {
-			duty = dmax
+// SetSpeedProfile sets an already parsed profile for a speed channel, rejecting it if StrictProfiles is set & it has issues
+func (d *KrakenDriver) SetSpeedProfile(channel string, profile SpeedProfile) error {
+	if _, ok := speedChannels[channel]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownChannel, channel)
+	}
+
+	if d.StrictProfiles {
+		if err := d.Curve.checkProfile(channel, profile); err != nil {
+			return err
 		}
+	}
+
+	points, err := d.setSpeedProfile(channel, profile)
+	if err != nil {
+		return err
+	}
+	d.State.recordSpeed(channel, AppliedSpeed{Method: SpeedProfileMethod, Profile: profile, Points: points})
+
+	return nil
+}
 
-		d.write([]byte{0x2, 0x4d, byte(cbase + i), byte(profile[0]), byte(duty)})
+// setSpeedProfile sets the effective profile of `profile` for a speed channel & returns its points
+func (d *KrakenDriver) setSpeedProfile(channel string, profile SpeedProfile) (SpeedProfile, error) {
+	p, err := d.Curve.EffectiveProfile(channel, profile)
+	if err != nil {
+		return nil, err
 	}
+
+	cbase := speedChannels[channel][0]
+	log.Infof("setting profile for channel '%s': %v", channel, p)
+
+	for i, point := range p {
+		if err := d.write([]byte{0x2, 0x4d, byte(cbase + i), byte(point[0]), byte(point[1])}); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
 }
 
 // SetFixedSpeed checks if device supports cooling profiles and then sets the provided duty for the channel either instant or not
-func (d *KrakenDriver) SetFixedSpeed(channel, duty string) {
-	if d.SupportsCoolingProfiles() {
-		d.SetSpeed(channel, "0 "+duty+"  59 "+duty+"  60 100  100 100")
-	} else {
-		d.setInstantSpeed(channel, duty)
+func (d *KrakenDriver) SetFixedSpeed(channel, duty string) error {
+	supported, err := d.SupportsCoolingProfiles()
+	if err != nil {
+		return err
 	}
-}
 
-// SupportsCoolingProfiles checks if the current firmware supports cooling profiles
-func (d *KrakenDriver) SupportsCoolingProfiles() bool {
-	if d.CoolingProfiles == false {
-		d.GetStatus()
+	if supported {
+		dutyInt, err := strconv.Atoi(duty)
+		if err != nil {
+			return fmt.Errorf("%w: duty %q is not a number", ErrInvalidProfile, duty)
+		}
+
+		points, err := d.setSpeedProfile(channel, flatProfile(d.Curve, dutyInt))
+		if err != nil {
+			return err
+		}
+		d.State.recordSpeed(channel, AppliedSpeed{Method: SpeedFixedMethod, Duty: clampDuty(channel, dutyInt), Points: points})
+
+		return nil
 	}
 
-	return d.FirmwareVersion[0] >= 3 && d.FirmwareVersion[1] >= 0 && d.FirmwareVersion[2] >= 0
+	return d.SetInstantSpeed(channel, duty)
 }
 
-// read reads from the USB device
-func (d *KrakenDriver) read() []byte {
-	var rdr contextReader = d.InEndpoint
-	if bufSize > 1 {
-		log.Print("creating buffer...")
-		s, err := d.InEndpoint.NewStream(readLength, bufSize)
-		if err != nil {
-			log.Fatalf("ep.NewStream(): %v", err)
+// SupportsCoolingProfiles checks if the current firmware supports cooling profiles
+func (d *KrakenDriver) SupportsCoolingProfiles() (bool, error) {
+	if d.CoolingProfiles == false {
+		if _, err := d.GetStatus(); err != nil {
+			return false, err
 		}
-		defer s.Close()
-		rdr = s
 	}
 
-	opCtx := context.Background()
+	return d.FirmwareVersion.AtLeast(FirmwareVersion{3, 0, 0}), nil
+}
+
+// read reads from the device
+func (d *KrakenDriver) read(ctx context.Context) ([]byte, error) {
+	opCtx := ctx
 	if timeout > 0 {
 		var done func()
 		opCtx, done = context.WithTimeout(opCtx, timeout)
 		defer done()
 	}
 	msg := make([]byte, readLength)
-	_, err := rdr.ReadContext(opCtx, msg)
+	_, err := d.Transport.ReadContext(opCtx, msg)
 	if err != nil {
-		log.Fatalf("reading from device failed: %v", err)
+		return nil, fmt.Errorf("reading from device failed: %w", err)
 	}
 	log.Infof("reading: %d", msg)
 	log.Infof("reading: % 02x", msg)
 
-	return msg
+	return msg, nil
 }
 
-// write writes to the USB device
-func (d *KrakenDriver) write(data []byte) {
+// write writes to the device
+func (d *KrakenDriver) write(data []byte) error {
 	padding := make([]byte, writeLength-len(data))
 	log.Infof("writing: %d", data)
 	log.Infof("writing: % 02x", data)
 	data = append(data, padding...)
-	_, err := d.OutEndpoint.Write(data)
+	_, err := d.Transport.Write(data)
 	if err != nil {
-		log.Fatalf("could not write data %d to device", data)
+		return fmt.Errorf("could not write data %d to device: %w", data, err)
 	}
+
+	return nil
 }
 
-// readFirmwareVersion reads the firmware version from `msg` and returns a formatted string
-func (d *KrakenDriver) readFirmwareVersion(msg []byte) string {
-	fwMajor, fwMinor, fwPatch := uint64(msg[0xb]), uint64(msg[0xc])<<8|uint64(msg[0xd]), uint64(msg[0xe])
-	d.FirmwareVersion = []int{int(fwMajor), int(fwMinor), int(fwPatch)}
+// readFirmwareVersion reads the firmware version from `msg` and remembers it
+func (d *KrakenDriver) readFirmwareVersion(msg []byte) FirmwareVersion {
+	d.FirmwareVersion = FirmwareVersion{
+		Major: int(msg[0xb]),
+		Minor: int(msg[0xc])<<8 | int(msg[0xd]),
+		Patch: int(msg[0xe]),
+	}
 	d.CoolingProfiles = true
 
-	return fmt.Sprintf("%d.%d.%d", fwMajor, fwMinor, fwPatch)
+	return d.FirmwareVersion
 }
 
-// setInstantSpeed sets a fixed speed per channel, but do not ensure persistence
-func (d *KrakenDriver) setInstantSpeed(channel, duty string) {
-	speedChannel, ok := speedChannels[channel]
-	if !ok {
-		log.Fatalf("channel %s not found", channel)
+// SetInstantSpeed sets a fixed speed per channel, but does not ensure persistence
+func (d *KrakenDriver) SetInstantSpeed(channel, duty string) error {
+	if _, ok := speedChannels[channel]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownChannel, channel)
 	}
 
 	dutyInt, err := strconv.Atoi(duty)
 	if err != nil {
-		log.Fatal(err)
+		return fmt.Errorf("%w: duty %q is not a number", ErrInvalidProfile, duty)
+	}
+
+	if err := d.setInstantDuty(channel, dutyInt); err != nil {
+		return err
+	}
+	d.State.recordSpeed(channel, AppliedSpeed{Method: SpeedInstantMethod, Duty: clampDuty(channel, dutyInt)})
+
+	return nil
+}
+
+// setInstantDuty clamps `duty` to the limits of the speed channel & sets it instantly
+func (d *KrakenDriver) setInstantDuty(channel string, duty int) error {
+	speedChannel, ok := speedChannels[channel]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownChannel, channel)
 	}
 
-	cbase, dmin, dmax := speedChannel[0], speedChannel[1], speedChannel[2]
-	if dutyInt < dmin {
-		dutyInt = dmin
-	} else if dutyInt > dmax {
-		dutyInt = dmax
+	duty = clampDuty(channel, duty)
+	log.Infof("setting instant duty for channel '%s': %d", channel, duty)
+
+	return d.write([]byte{0x2, 0x4d, byte(speedChannel[0] & 0x70), 0, byte(duty)})
+}
+
+// ClampDuty returns the duty `channel` actually runs at when set to `duty`
+func ClampDuty(channel string, duty int) (int, error) {
+	if _, ok := speedChannels[channel]; !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownChannel, channel)
+	}
+
+	return clampDuty(channel, duty), nil
+}
+
+// clampDuty limits `duty` to what the speed channel can run at
+func clampDuty(channel string, duty int) int {
+	speedChannel := speedChannels[channel]
+	if dmin, dmax := speedChannel[1], speedChannel[2]; duty < dmin {
+		return dmin
+	} else if duty > dmax {
+		return dmax
 	}
 
-	d.write([]byte{0x2, 0x4d, byte(cbase & 0x70), 0, byte(dutyInt)})
+	return duty
 }
@@ -5,28 +5,204 @@
 package driver
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
 
 var supportCoolingProfilesTest = []struct {
-	in  []int
+	in  FirmwareVersion
 	out bool
 }{
-	{[]int{2, 9, 9}, false},
-	{[]int{2, 0, 0}, false},
-	{[]int{3, 0, 0}, true},
-	{[]int{6, 0, 0}, true},
-	{[]int{6, 0, 2}, true},
+	{FirmwareVersion{2, 9, 9}, false},
+	{FirmwareVersion{2, 0, 0}, false},
+	{FirmwareVersion{3, 0, 0}, true},
+	{FirmwareVersion{6, 0, 0}, true},
+	{FirmwareVersion{6, 0, 2}, true},
 }
 
 func TestSupportsCoolingProfiles(t *testing.T) {
 	for _, tt := range supportCoolingProfilesTest {
-		t.Run(fmt.Sprintf("%d.%d.%d", tt.in[0], tt.in[1], tt.in[2]), func(t *testing.T) {
+		t.Run(tt.in.String(), func(t *testing.T) {
 			kraken := KrakenDriver{FirmwareVersion: tt.in, CoolingProfiles: true}
-			assert.Equal(t, tt.out, kraken.SupportsCoolingProfiles())
+			supported, err := kraken.SupportsCoolingProfiles()
+			assert.Equal(t, tt.out, supported)
+			assert.Nil(t, err)
 		})
 	}
 }
+
+// statusReport returns a status report for 32.7 °C, 527 rpm fan, 2040 rpm pump & firmware 6.0.2
+func statusReport() []byte {
+	msg := make([]byte, readLength)
+	msg[1], msg[2] = 32, 7
+	msg[3], msg[4] = 0x02, 0x0f
+	msg[5], msg[6] = 0x07, 0xf8
+	msg[0xb], msg[0xc], msg[0xd], msg[0xe] = 6, 0, 0, 2
+
+	return msg
+}
+
+func TestGetStatus(t *testing.T) {
+	kraken := NewKrakenDriverWithTransport(NewMemoryTransport(statusReport()))
+
+	status, err := kraken.GetStatus()
+
+	assert.Nil(t, err)
+	assert.InDelta(t, 32.7, status.LiquidTemperature, 1e-9)
+	assert.Equal(t, 527, status.FanSpeed)
+	assert.Equal(t, 2040, status.PumpSpeed)
+	assert.Equal(t, FirmwareVersion{6, 0, 2}, status.FirmwareVersion)
+	assert.Equal(t, FirmwareVersion{6, 0, 2}, kraken.FirmwareVersion)
+	assert.WithinDuration(t, time.Now(), status.Time, time.Second)
+}
+
+func TestGetStatusReadError(t *testing.T) {
+	kraken := NewKrakenDriverWithTransport(NewMemoryTransport())
+
+	_, err := kraken.GetStatus()
+
+	assert.Error(t, err)
+}
+
+func TestSetColor(t *testing.T) {
+	transport := NewMemoryTransport()
+	kraken := NewKrakenDriverWithTransport(transport)
+
+	err := kraken.SetColor("ring", "fading", "normal", []string{"ff0000", "00ff00"})
+
+	assert.Nil(t, err)
+	assert.Len(t, transport.Writes, 2)
+	for _, report := range transport.Writes {
+		assert.Len(t, report, writeLength)
+	}
+	assert.Equal(t, []byte{0x2, 0x4c, 0x2, 0x1, 0x2, 0x00, 0xff, 0x00, 0xff, 0x00, 0x00}, transport.Writes[0][:11])
+	assert.Equal(t, []byte{0x2, 0x4c, 0x2, 0x1, 0x22, 0xff, 0x00, 0x00, 0x00, 0xff, 0x00}, transport.Writes[1][:11])
+}
+
+func TestSetColorSpeed(t *testing.T) {
+	transport := NewMemoryTransport()
+	kraken := NewKrakenDriverWithTransport(transport)
+
+	err := kraken.SetColor("ring", "marquee-4", "fastest", []string{"ff0000"})
+
+	assert.Nil(t, err)
+	assert.Len(t, transport.Writes, 1)
+	assert.Equal(t, []byte{0x2, 0x4c, 0x2, 0x3, 0x0c}, transport.Writes[0][:5])
+}
+
+var setColorErrorTests = []struct {
+	channel, mode, speed string
+	colors               []string
+	err                  error
+}{
+	{"case", "fixed", "normal", []string{"ff0000"}, ErrUnknownChannel},
+	{"ring", "disco", "normal", []string{"ff0000"}, ErrUnknownMode},
+	{"ring", "fading", "ludicrous", []string{"ff0000", "00ff00"}, ErrUnknownSpeed},
+	{"ring", "fading", "", []string{"ff0000", "00ff00"}, ErrUnknownSpeed},
+	{"logo", "loading", "normal", []string{"ff0000"}, ErrUnsupportedMode},
+	{"ring", "fading", "normal", []string{"ff0000"}, ErrNotEnoughColors},
+	{"ring", "fixed", "normal", []string{"foobar"}, ErrInvalidColor},
+}
+
+func TestSetColorErrors(t *testing.T) {
+	for _, tt := range setColorErrorTests {
+		t.Run(tt.channel+" "+tt.mode+" "+tt.speed, func(t *testing.T) {
+			transport := NewMemoryTransport()
+			kraken := NewKrakenDriverWithTransport(transport)
+
+			err := kraken.SetColor(tt.channel, tt.mode, tt.speed, tt.colors)
+
+			assert.True(t, errors.Is(err, tt.err))
+			assert.Empty(t, transport.Writes)
+		})
+	}
+}
+
+func TestSetSpeed(t *testing.T) {
+	transport := NewMemoryTransport()
+	kraken := NewKrakenDriverWithTransport(transport)
+
+	err := kraken.SetSpeed("pump", "20 25  35 25  50 55  60 100")
+
+	assert.Nil(t, err)
+	assert.Len(t, transport.Writes, 21)
+	assert.Equal(t, []byte{0x2, 0x4d, 0xc0, 20, 50}, transport.Writes[0][:5])
+	assert.Equal(t, []byte{0x2, 0x4d, 0xcf, 50, 55}, transport.Writes[15][:5])
+	assert.Equal(t, []byte{0x2, 0x4d, 0xd4, 60, 100}, transport.Writes[20][:5])
+}
+
+func TestSetSpeedErrors(t *testing.T) {
+	kraken := NewKrakenDriverWithTransport(NewMemoryTransport())
+
+	assert.True(t, errors.Is(kraken.SetSpeed("case", "20 25"), ErrUnknownChannel))
+	assert.True(t, errors.Is(kraken.SetSpeed("fan", "20"), ErrInvalidProfile))
+}
+
+func TestSetFixedSpeed(t *testing.T) {
+	transport := NewMemoryTransport(statusReport())
+	kraken := NewKrakenDriverWithTransport(transport)
+
+	err := kraken.SetFixedSpeed("fan", "40")
+
+	assert.Nil(t, err)
+	assert.Len(t, transport.Writes, 21)
+	assert.Equal(t, []byte{0x2, 0x4d, 0x80, 20, 40}, transport.Writes[0][:5])
+}
+
+func TestSetFixedSpeedInstant(t *testing.T) {
+	msg := statusReport()
+	msg[0xb] = 2
+	transport := NewMemoryTransport(msg)
+	kraken := NewKrakenDriverWithTransport(transport)
+
+	err := kraken.SetFixedSpeed("fan", "40")
+
+	assert.Nil(t, err)
+	assert.Len(t, transport.Writes, 1)
+	assert.Equal(t, []byte{0x2, 0x4d, 0x00, 0, 40}, transport.Writes[0][:5])
+}
+
+func TestGetStatusContextCanceled(t *testing.T) {
+	kraken := NewKrakenDriverWithTransport(NewMemoryTransport(statusReport()))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := kraken.GetStatusContext(ctx)
+
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestEffectiveProfile(t *testing.T) {
+	profile := SpeedProfile{{45, 70}, {30, 40}}
+
+	p, err := DefaultCurve.EffectiveProfile("pump", profile)
+
+	assert.Nil(t, err)
+	assert.Len(t, p, 21)
+	assert.Equal(t, []int{20, 50}, p[0])
+	assert.Equal(t, []int{44, 68}, p[12])
+	assert.Equal(t, []int{60, 100}, p[20])
+	assert.Equal(t, SpeedProfile{{45, 70}, {30, 40}}, profile)
+}
+
+func TestClampDuty(t *testing.T) {
+	duty, err := ClampDuty("fan", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 25, duty)
+
+	duty, err = ClampDuty("pump", 70)
+	assert.Nil(t, err)
+	assert.Equal(t, 70, duty)
+
+	_, err = ClampDuty("case", 40)
+	assert.True(t, errors.Is(err, ErrUnknownChannel))
+}
+
+func TestValidSpeed(t *testing.T) {
+	assert.Nil(t, ValidSpeed("fastest"))
+	assert.True(t, errors.Is(ValidSpeed("bogus"), ErrUnknownSpeed))
+}
@@ -0,0 +1,141 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ringLEDs is the number of LEDs in the ring, the remaining one is the logo
+const ringLEDs = totalLEDs - 1
+
+// LEDAddressed checks if `colors` address single LEDs, e.g. logo=FF0000 ring[0-3]=00FF00
+func LEDAddressed(colors []string) bool {
+	for _, c := range colors {
+		if strings.Contains(c, "=") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AddressLEDs turns colors addressed to single LEDs into the colors of all LEDs in the order of super mode `mode`,
+// e.g. logo=FF0000 ring[0-3]=00FF00 ring[4,6]=0000FF or ring=00FF00 for the whole ring.
+// LEDs without a color are set to `fill`, or turned off if it is empty.
+func AddressLEDs(mode string, colors []string, fill string) ([]string, error) {
+	colorMode, ok := colorModes[mode]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownMode, mode)
+	}
+	if !strings.HasPrefix(mode, "super-") && !strings.HasPrefix(mode, "backwards-super-") {
+		return nil, fmt.Errorf("%w: %s with single LEDs, use super-fixed, super-breathing or super-wave", ErrUnsupportedMode, mode)
+	}
+	ringonly := colorMode[5] == 1
+
+	if fill == "" {
+		fill = "000000"
+	} else if _, err := ParseColor(fill); err != nil {
+		return nil, err
+	}
+
+	// the logo first, then the ring
+	leds := make([]string, totalLEDs)
+	for i := range leds {
+		leds[i] = fill
+	}
+
+	for _, c := range colors {
+		target, value := splitLEDColor(c)
+		if target == "" {
+			return nil, fmt.Errorf("%w %q: address an LED, e.g. logo=FF0000, ring[0-3]=00FF00 or ring=0000FF", ErrInvalidColor, c)
+		}
+		if ringonly && target == "logo" {
+			return nil, fmt.Errorf("%w: %s with the logo, it only lights the ring", ErrUnsupportedMode, mode)
+		}
+		if _, err := ParseColor(value); err != nil {
+			return nil, err
+		}
+
+		indices, err := ledIndices(target)
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %v", ErrInvalidColor, c, err)
+		}
+
+		for _, i := range indices {
+			leds[i] = value
+		}
+	}
+
+	if ringonly {
+		return leds[1:], nil
+	}
+
+	return leds, nil
+}
+
+// splitLEDColor splits logo=FF0000 into logo & FF0000, the target is empty if there is no =
+func splitLEDColor(c string) (string, string) {
+	i := strings.Index(c, "=")
+	if i < 0 {
+		return "", c
+	}
+
+	return strings.ToLower(strings.TrimSpace(c[:i])), c[i+1:]
+}
+
+// ledIndices returns the LEDs of logo, ring or ring[0-3,5], the logo is 0 & the ring starts at 1
+func ledIndices(target string) ([]int, error) {
+	switch {
+	case target == "logo":
+		return []int{0}, nil
+	case target == "ring":
+		return makeRange(1, totalLEDs, 1), nil
+	case !strings.HasPrefix(target, "ring[") || !strings.HasSuffix(target, "]"):
+		return nil, fmt.Errorf("unknown LED %s (e.g: logo, ring or ring[0-%d])", target, ringLEDs-1)
+	}
+
+	var indices []int
+	for _, item := range strings.Split(target[len("ring["):len(target)-1], ",") {
+		item = strings.TrimSpace(item)
+		from, to := item, item
+		if i := strings.LastIndex(item, "-"); i > 0 {
+			from, to = item[:i], item[i+1:]
+		}
+
+		first, err := ringIndex(from)
+		if err != nil {
+			return nil, err
+		}
+		last, err := ringIndex(to)
+		if err != nil {
+			return nil, err
+		}
+		if last < first {
+			return nil, fmt.Errorf("ring LED range %s is reversed", item)
+		}
+
+		for i := first; i <= last; i++ {
+			indices = append(indices, i+1)
+		}
+	}
+
+	return indices, nil
+}
+
+// ringIndex parses the index of a ring LED
+func ringIndex(s string) (int, error) {
+	i, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("ring LED %q is not a number", strings.TrimSpace(s))
+	}
+	if i < 0 || i >= ringLEDs {
+		return 0, fmt.Errorf("ring LED %d does not exist, the ring has LEDs 0-%d", i, ringLEDs-1)
+	}
+
+	return i, nil
+}
@@ -0,0 +1,84 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLEDAddressed(t *testing.T) {
+	assert.True(t, LEDAddressed([]string{"logo=FF0000"}))
+	assert.False(t, LEDAddressed([]string{"FF0000", "00FF00"}))
+}
+
+var addressLEDsTests = []struct {
+	mode   string
+	colors []string
+	fill   string
+	out    []string
+}{
+	{"super-fixed", []string{"logo=FF0000", "ring[0-3]=00FF00", "ring[4,6]=0000FF"}, "", []string{
+		"FF0000", "00FF00", "00FF00", "00FF00", "00FF00", "0000FF", "000000", "0000FF", "000000",
+	}},
+	{"super-breathing", []string{"ring[7]=red"}, "white", []string{
+		"white", "white", "white", "white", "white", "white", "white", "white", "red",
+	}},
+	{"super-fixed", []string{"ring=00FF00", "ring[2]=FF0000"}, "", []string{
+		"000000", "00FF00", "00FF00", "FF0000", "00FF00", "00FF00", "00FF00", "00FF00", "00FF00",
+	}},
+	{"super-wave", []string{"ring[0,2-3]=FF0000"}, "", []string{
+		"FF0000", "000000", "FF0000", "FF0000", "000000", "000000", "000000", "000000",
+	}},
+	{"backwards-super-wave", nil, "blue", []string{
+		"blue", "blue", "blue", "blue", "blue", "blue", "blue", "blue",
+	}},
+}
+
+func TestAddressLEDs(t *testing.T) {
+	for _, tt := range addressLEDsTests {
+		t.Run(tt.mode+" "+strings.Join(tt.colors, " "), func(t *testing.T) {
+			leds, err := AddressLEDs(tt.mode, tt.colors, tt.fill)
+
+			assert.Nil(t, err)
+			assert.Equal(t, tt.out, leds)
+		})
+	}
+}
+
+var addressLEDsInvalidTests = []struct {
+	mode   string
+	colors []string
+	fill   string
+	err    error
+	msg    string
+}{
+	{"disco", []string{"logo=FF0000"}, "", ErrUnknownMode, "unknown mode: disco"},
+	{"fading", []string{"logo=FF0000"}, "", ErrUnsupportedMode, "fading with single LEDs"},
+	{"super-wave", []string{"logo=FF0000"}, "", ErrUnsupportedMode, "super-wave with the logo"},
+	{"super-fixed", []string{"logo=FF0000", "00FF00"}, "", ErrInvalidColor, `"00FF00": address an LED`},
+	{"super-fixed", []string{"ring[8]=FF0000"}, "", ErrInvalidColor, "ring LED 8 does not exist, the ring has LEDs 0-7"},
+	{"super-fixed", []string{"ring[-1]=FF0000"}, "", ErrInvalidColor, "ring LED -1 does not exist"},
+	{"super-fixed", []string{"ring[one]=FF0000"}, "", ErrInvalidColor, `ring LED "one" is not a number`},
+	{"super-fixed", []string{"ring[5-2]=FF0000"}, "", ErrInvalidColor, "ring LED range 5-2 is reversed"},
+	{"super-fixed", []string{"fan=FF0000"}, "", ErrInvalidColor, "unknown LED fan"},
+	{"super-fixed", []string{"logo=FF"}, "", ErrInvalidColor, `invalid color "FF"`},
+	{"super-fixed", []string{"logo=FF0000"}, "mauve-ish", ErrInvalidColor, `invalid color "mauve-ish"`},
+}
+
+func TestAddressLEDsInvalid(t *testing.T) {
+	for _, tt := range addressLEDsInvalidTests {
+		t.Run(tt.mode+" "+strings.Join(tt.colors, " "), func(t *testing.T) {
+			leds, err := AddressLEDs(tt.mode, tt.colors, tt.fill)
+
+			assert.Nil(t, leds)
+			assert.True(t, errors.Is(err, tt.err))
+			assert.Contains(t, err.Error(), tt.msg)
+		})
+	}
+}
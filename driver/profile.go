@@ -0,0 +1,132 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// SpeedProfiles holds speed profiles by speed channel
+type SpeedProfiles map[string]SpeedProfile
+
+// Channels returns the speed channels with a profile, sorted by name
+func (p SpeedProfiles) Channels() []string {
+	var channels []string
+	for channel := range p {
+		channels = append(channels, channel)
+	}
+	sort.Strings(channels)
+
+	return channels
+}
+
+// ParseSpeedProfile parses temperature & duty pairs, all of these are the same profile:
+//
+//	20 25  35 25  50 55  60 100
+//	20:25,35:25,50:55,60:100
+//	20°C:25% 35C:25% 50C:55% 60C:100%
+//	68F:25 95F:25 122F:55 140F:100
+func ParseSpeedProfile(s string) (SpeedProfile, error) {
+	return parseProfile(s)
+}
+
+// ProfileFormat guesses the format of a profile file from its name, returns an empty string if unknown
+func ProfileFormat(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".toml":
+		return "toml"
+	case ".json":
+		return "json"
+	}
+
+	return ""
+}
+
+// ReadSpeedProfiles reads speed profiles in `format` (yaml, toml, json or empty to guess) from `r`,
+// e.g. in YAML:
+//
+//	fan: [[20, 25], [35, 25], [50, 55], [60, 100]]
+//	pump: [[20, 60], [35, 60], [55, 100]]
+func ReadSpeedProfiles(r io.Reader, format string) (SpeedProfiles, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == "" {
+		format = guessProfileFormat(b)
+	}
+
+	var raw map[string][][]int
+	switch format {
+	case "yaml":
+		err = yaml.UnmarshalStrict(b, &raw)
+	case "toml":
+		_, err = toml.Decode(string(b), &raw)
+	case "json":
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&raw)
+	default:
+		return nil, fmt.Errorf("%w: unknown file format %q", ErrInvalidProfile, format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidProfile, err)
+	}
+
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("%w: no profiles found", ErrInvalidProfile)
+	}
+
+	profiles := SpeedProfiles{}
+	for channel, points := range raw {
+		if _, ok := speedChannels[channel]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownChannel, channel)
+		}
+
+		if len(points) == 0 {
+			return nil, fmt.Errorf("%w: empty profile for channel %s", ErrInvalidProfile, channel)
+		}
+
+		for _, point := range points {
+			if len(point) != 2 {
+				return nil, fmt.Errorf("%w: point %v for channel %s is not a temperature & duty pair", ErrInvalidProfile, point, channel)
+			}
+		}
+
+		profiles[channel] = SpeedProfile(points)
+	}
+
+	return profiles, nil
+}
+
+// guessProfileFormat guesses the format of `b`, JSON is read as YAML
+func guessProfileFormat(b []byte) string {
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.Contains(line, "=") && !strings.HasPrefix(line, "{") {
+			return "toml"
+		}
+		break
+	}
+
+	return "yaml"
+}
@@ -0,0 +1,89 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var silentProfiles = SpeedProfiles{
+	"fan":  {{20, 25}, {35, 25}, {50, 55}, {60, 100}},
+	"pump": {{20, 60}, {35, 60}, {55, 100}},
+}
+
+var readSpeedProfilesTests = []struct {
+	name   string
+	format string
+	in     string
+}{
+	{"yaml", "yaml", "fan: [[20, 25], [35, 25], [50, 55], [60, 100]]\npump: [[20, 60], [35, 60], [55, 100]]\n"},
+	{"yaml block", "yaml", "fan:\n  - [20, 25]\n  - [35, 25]\n  - [50, 55]\n  - [60, 100]\npump:\n  - [20, 60]\n  - [35, 60]\n  - [55, 100]\n"},
+	{"toml", "toml", "fan = [[20, 25], [35, 25], [50, 55], [60, 100]]\npump = [[20, 60], [35, 60], [55, 100]]\n"},
+	{"json", "json", `{"fan": [[20, 25], [35, 25], [50, 55], [60, 100]], "pump": [[20, 60], [35, 60], [55, 100]]}`},
+	{"guess yaml", "", "# silent\nfan: [[20, 25], [35, 25], [50, 55], [60, 100]]\npump: [[20, 60], [35, 60], [55, 100]]\n"},
+	{"guess toml", "", "# silent\nfan = [[20, 25], [35, 25], [50, 55], [60, 100]]\npump = [[20, 60], [35, 60], [55, 100]]\n"},
+	{"guess json", "", `{"fan": [[20, 25], [35, 25], [50, 55], [60, 100]], "pump": [[20, 60], [35, 60], [55, 100]]}`},
+}
+
+func TestReadSpeedProfiles(t *testing.T) {
+	for _, tt := range readSpeedProfilesTests {
+		t.Run(tt.name, func(t *testing.T) {
+			profiles, err := ReadSpeedProfiles(strings.NewReader(tt.in), tt.format)
+
+			require.Nil(t, err)
+			assert.Equal(t, silentProfiles, profiles)
+			assert.Equal(t, []string{"fan", "pump"}, profiles.Channels())
+		})
+	}
+}
+
+var readSpeedProfilesErrorTests = []struct {
+	name   string
+	format string
+	in     string
+	err    error
+}{
+	{"empty", "yaml", "", ErrInvalidProfile},
+	{"unknown channel", "yaml", "case: [[20, 25]]", ErrUnknownChannel},
+	{"empty profile", "toml", "fan = []", ErrInvalidProfile},
+	{"single value", "json", `{"fan": [[20, 25], [35]]}`, ErrInvalidProfile},
+	{"not a number", "yaml", "fan: [[20, fast]]", ErrInvalidProfile},
+	{"malformed", "json", `{"fan": `, ErrInvalidProfile},
+	{"unknown format", "ini", "fan=20", ErrInvalidProfile},
+}
+
+func TestReadSpeedProfilesInvalid(t *testing.T) {
+	for _, tt := range readSpeedProfilesErrorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadSpeedProfiles(strings.NewReader(tt.in), tt.format)
+
+			assert.True(t, errors.Is(err, tt.err), "%v", err)
+		})
+	}
+}
+
+func TestProfileFormat(t *testing.T) {
+	assert.Equal(t, "yaml", ProfileFormat("silent.yml"))
+	assert.Equal(t, "toml", ProfileFormat("/etc/coolctl/silent.TOML"))
+	assert.Equal(t, "json", ProfileFormat("silent.json"))
+	assert.Equal(t, "", ProfileFormat("silent"))
+}
+
+func TestSetSpeedProfileMatchesSetSpeed(t *testing.T) {
+	fromString := NewMemoryTransport()
+	err := NewKrakenDriverWithTransport(fromString).SetSpeed("fan", "20 25  35 25  50 55  60 100")
+	require.Nil(t, err)
+
+	fromFile := NewMemoryTransport()
+	err = NewKrakenDriverWithTransport(fromFile).SetSpeedProfile("fan", silentProfiles["fan"])
+	require.Nil(t, err)
+
+	assert.Equal(t, fromString.Writes, fromFile.Writes)
+}
@@ -0,0 +1,94 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DeviceSelector picks one of several connected devices, zero values & a negative Index match any device
+type DeviceSelector struct {
+	SerialNumber string
+	Bus          int
+	Address      int
+	PortPath     string
+	Index        int
+}
+
+// AnyDevice selects the first connected device
+var AnyDevice = DeviceSelector{Index: -1}
+
+// IsAny checks if the selector matches any device
+func (s DeviceSelector) IsAny() bool {
+	return s.SerialNumber == "" && s.Bus == 0 && s.Address == 0 && s.PortPath == "" && s.Index < 0
+}
+
+// Select returns the only device in `devices` matching the selector, or the first one if the selector matches any device
+func (s DeviceSelector) Select(devices []DeviceInfo) (DeviceInfo, error) {
+	if len(devices) == 0 {
+		return DeviceInfo{}, ErrDeviceNotFound
+	}
+
+	if s.IsAny() {
+		if len(devices) > 1 {
+			log.Warnf("%d devices found, using the first one", len(devices))
+		}
+		return devices[0], nil
+	}
+
+	var matches []DeviceInfo
+	for i, device := range devices {
+		if s.matches(i, device) {
+			matches = append(matches, device)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return DeviceInfo{}, fmt.Errorf("%w with %s", ErrDeviceNotFound, s)
+	case 1:
+		return matches[0], nil
+	default:
+		return DeviceInfo{}, fmt.Errorf("%w: %d devices with %s", ErrAmbiguousDevice, len(matches), s)
+	}
+}
+
+// String describes the criteria of the selector
+func (s DeviceSelector) String() string {
+	var criteria []string
+	if s.SerialNumber != "" {
+		criteria = append(criteria, fmt.Sprintf("serial number %s", s.SerialNumber))
+	}
+	if s.Bus != 0 {
+		criteria = append(criteria, fmt.Sprintf("bus %d", s.Bus))
+	}
+	if s.Address != 0 {
+		criteria = append(criteria, fmt.Sprintf("address %d", s.Address))
+	}
+	if s.PortPath != "" {
+		criteria = append(criteria, fmt.Sprintf("port path %s", s.PortPath))
+	}
+	if s.Index >= 0 {
+		criteria = append(criteria, fmt.Sprintf("index %d", s.Index))
+	}
+
+	if len(criteria) == 0 {
+		return "any device"
+	}
+
+	return strings.Join(criteria, ", ")
+}
+
+// matches checks if the `i`th device `device` matches all criteria
+func (s DeviceSelector) matches(i int, device DeviceInfo) bool {
+	return (s.SerialNumber == "" || s.SerialNumber == device.SerialNumber) &&
+		(s.Bus == 0 || s.Bus == device.Bus) &&
+		(s.Address == 0 || s.Address == device.Address) &&
+		(s.PortPath == "" || s.PortPath == device.PortPath) &&
+		(s.Index < 0 || s.Index == i)
+}
@@ -0,0 +1,66 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// selectorDevices have port paths as the usb backend formats them, two behind different hubs on the same port
+var selectorDevices = []DeviceInfo{
+	{Backend: "usb", Bus: 1, PortPath: formatPortPath([]int{1, 4}), Address: 5, SerialNumber: "CPU0"},
+	{Backend: "usb", Bus: 1, PortPath: formatPortPath([]int{3, 4}), Address: 7, SerialNumber: "GPU0"},
+	{Backend: "usb", Bus: 2, PortPath: formatPortPath([]int{1, 4}), Address: 5, SerialNumber: "GPU1"},
+}
+
+var selectTests = []struct {
+	name     string
+	selector DeviceSelector
+	out      int
+	err      error
+}{
+	{"any", AnyDevice, 0, nil},
+	{"serial", DeviceSelector{SerialNumber: "GPU0", Index: -1}, 1, nil},
+	{"bus & address", DeviceSelector{Bus: 2, Address: 5, Index: -1}, 2, nil},
+	{"port path", DeviceSelector{PortPath: "3.4", Index: -1}, 1, nil},
+	{"bus & port path", DeviceSelector{Bus: 2, PortPath: "1.4", Index: -1}, 2, nil},
+	{"index", DeviceSelector{Index: 2}, 2, nil},
+	{"serial & index", DeviceSelector{SerialNumber: "CPU0", Index: 0}, 0, nil},
+	{"ambiguous address", DeviceSelector{Address: 5, Index: -1}, 0, ErrAmbiguousDevice},
+	{"ambiguous port path", DeviceSelector{PortPath: "1.4", Index: -1}, 0, ErrAmbiguousDevice},
+	{"last port only", DeviceSelector{PortPath: "4", Index: -1}, 0, ErrDeviceNotFound},
+	{"unknown serial", DeviceSelector{SerialNumber: "GPU2", Index: -1}, 0, ErrDeviceNotFound},
+	{"conflicting serial & index", DeviceSelector{SerialNumber: "CPU0", Index: 1}, 0, ErrDeviceNotFound},
+	{"index out of range", DeviceSelector{Index: 3}, 0, ErrDeviceNotFound},
+}
+
+func TestSelect(t *testing.T) {
+	for _, tt := range selectTests {
+		t.Run(tt.name, func(t *testing.T) {
+			device, err := tt.selector.Select(selectorDevices)
+
+			if tt.err != nil {
+				assert.True(t, errors.Is(err, tt.err))
+			} else {
+				assert.Nil(t, err)
+				assert.Equal(t, selectorDevices[tt.out], device)
+			}
+		})
+	}
+}
+
+func TestSelectNoDevices(t *testing.T) {
+	_, err := AnyDevice.Select(nil)
+
+	assert.Equal(t, ErrDeviceNotFound, err)
+}
+
+func TestSelectorString(t *testing.T) {
+	assert.Equal(t, "any device", AnyDevice.String())
+	assert.Equal(t, "serial number GPU0, bus 1, address 7, port path 3.4, index 1", DeviceSelector{"GPU0", 1, 7, "3.4", 1}.String())
+}
@@ -0,0 +1,262 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"context"
+	"fmt"
+	"image/color"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	ambientTemp = 25.0 // °C, room temperature
+	heatLoad    = 15.0 // °C, liquid temperature above ambient with the fan & pump stopped
+	thermalTau  = 30.0 // s, time constant of the liquid temperature
+	rotorTau    = 2.0  // s, time constant of the fan & pump speeds
+	maxStep     = 0.1  // s, longest step the simulation is advanced by at once
+	maxElapsed  = 3600 // s, longest time the simulation catches up on
+	maxFanRPM   = 2000
+	maxPumpRPM  = 2800
+)
+
+func init() {
+	backends["simulator"] = simulatorBackend{}
+}
+
+// simulatorBackend always finds a single, fresh Simulator
+type simulatorBackend struct{}
+
+// Devices lists the simulated device
+func (simulatorBackend) Devices(vid, pid uint16) ([]DeviceInfo, error) {
+	return []DeviceInfo{SimulatorDevice}, nil
+}
+
+// Open returns a fresh Simulator
+func (simulatorBackend) Open(info DeviceInfo) (Transport, error) {
+	return NewSimulator(), nil
+}
+
+// SimulatorDevice describes the simulated device
+var SimulatorDevice = DeviceInfo{
+	Backend:      "simulator",
+	Manufacturer: "coolctl",
+	Product:      "Simulated Kraken X",
+	SerialNumber: "SIMULATED",
+}
+
+// SimulatedLighting is the lighting state of a color channel as uploaded to the Simulator
+type SimulatedLighting struct {
+	Mode     byte
+	Reverse  byte
+	Modifier byte
+	Speed    byte
+	Steps    []color.Palette // logo + 8 ring leds per step
+}
+
+// Simulator is a virtual Kraken X implementing Transport, it decodes color & speed reports and
+// produces status reports whose temperature & speeds follow the configured duties
+type Simulator struct {
+	FirmwareVersion FirmwareVersion
+	Curves          map[string]SpeedProfile      // uploaded curves per speed channel
+	Duties          map[string]int               // instant duties per speed channel, override the curves
+	Lighting        map[string]SimulatedLighting // per color channel
+	Temperature     float64                      // °C
+	FanSpeed        float64                      // rpm
+	PumpSpeed       float64                      // rpm
+	Now             func() time.Time
+	Closed          bool
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+// NewSimulator returns a Simulator with firmware 6.0.2 running at the firmware's default duties
+func NewSimulator() *Simulator {
+	s := &Simulator{
+		FirmwareVersion: FirmwareVersion{6, 0, 2},
+		Curves:          map[string]SpeedProfile{},
+		Duties:          map[string]int{"fan": 25, "pump": 60},
+		Lighting:        map[string]SimulatedLighting{},
+		Temperature:     30,
+		Now:             time.Now,
+	}
+	s.FanSpeed, s.PumpSpeed = s.targetSpeeds()
+	s.last = s.Now()
+
+	return s
+}
+
+// Duty returns the current duty of `channel`
+func (s *Simulator) Duty(channel string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.duty(channel)
+}
+
+// ReadContext advances the simulation & returns a status report
+func (s *Simulator) ReadContext(ctx context.Context, report []byte) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.advance()
+
+	msg := make([]byte, readLength)
+	temp := math.Round(s.Temperature*10) / 10
+	msg[0] = 0x04
+	msg[1], msg[2] = byte(temp), byte(math.Round((temp-math.Floor(temp))*10))
+	msg[3], msg[4] = byte(int(s.FanSpeed)>>8), byte(int(s.FanSpeed))
+	msg[5], msg[6] = byte(int(s.PumpSpeed)>>8), byte(int(s.PumpSpeed))
+	msg[0xb] = byte(s.FirmwareVersion.Major)
+	msg[0xc], msg[0xd] = byte(s.FirmwareVersion.Minor>>8), byte(s.FirmwareVersion.Minor)
+	msg[0xe] = byte(s.FirmwareVersion.Patch)
+
+	return copy(report, msg), nil
+}
+
+// Write decodes a color or speed report
+func (s *Simulator) Write(report []byte) (int, error) {
+	if len(report) != writeLength || report[0] != 0x2 {
+		return 0, fmt.Errorf("simulator: malformed report % 02x", report)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.advance()
+
+	switch report[1] {
+	case 0x4c:
+		s.writeColor(report)
+	case 0x4d:
+		s.writeSpeed(report)
+	default:
+		return 0, fmt.Errorf("simulator: unsupported report % 02x", report[:2])
+	}
+
+	return len(report), nil
+}
+
+// Close marks the simulator as closed
+func (s *Simulator) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Closed = true
+
+	return nil
+}
+
+// writeColor decodes a 0x2 0x4c lighting report, a sequence number of 0 starts a new set of steps
+func (s *Simulator) writeColor(report []byte) {
+	channel := ""
+	for name, value := range colorChannels {
+		if int(report[2]&0x07) == value {
+			channel = name
+		}
+	}
+
+	seq := int(report[4] >> 5)
+	lighting := s.Lighting[channel]
+	if seq == 0 {
+		lighting = SimulatedLighting{
+			Mode:     report[3],
+			Reverse:  report[2] &^ 0x07,
+			Modifier: report[4] & 0x18,
+			Speed:    report[4] & 0x07,
+		}
+	}
+
+	// the logo color is sent as GRB, the ring leds as RGB
+	step := color.Palette{color.RGBA{R: report[6], G: report[5], B: report[7], A: 1}}
+	for led := 0; led < totalLEDs-1; led++ {
+		i := 8 + 3*led
+		step = append(step, color.RGBA{R: report[i], G: report[i+1], B: report[i+2], A: 1})
+	}
+	lighting.Steps = append(lighting.Steps, step)
+
+	s.Lighting[channel] = lighting
+}
+
+// writeSpeed decodes a 0x2 0x4d speed report, either a curve point or an instant duty
+func (s *Simulator) writeSpeed(report []byte) {
+	channel := "fan"
+	if report[2]&0x40 != 0 {
+		channel = "pump"
+	}
+
+	if report[2]&0x80 == 0 {
+		s.Duties[channel] = int(report[4])
+		delete(s.Curves, channel)
+		return
+	}
+
+	i := int(report[2] & 0x1f)
+	curve := s.Curves[channel]
+	for len(curve) <= i {
+		curve = append(curve, []int{0, 0})
+	}
+	curve[i] = []int{int(report[3]), int(report[4])}
+
+	s.Curves[channel] = curve
+	delete(s.Duties, channel)
+}
+
+// duty returns the duty of `channel` at the current temperature
+func (s *Simulator) duty(channel string) int {
+	if duty, ok := s.Duties[channel]; ok {
+		return duty
+	}
+
+	duty := 100
+	for _, point := range s.Curves[channel] {
+		if float64(point[0]) >= s.Temperature {
+			duty = point[1]
+			break
+		}
+	}
+
+	return duty
+}
+
+// targetSpeeds returns the fan & pump speeds the rotors are heading to
+func (s *Simulator) targetSpeeds() (float64, float64) {
+	return float64(s.duty("fan")) / 100 * maxFanRPM, float64(s.duty("pump")) / 100 * maxPumpRPM
+}
+
+// advance moves the simulation forward to now, in steps of at most maxStep
+func (s *Simulator) advance() {
+	now := s.Now()
+	elapsed := math.Min(now.Sub(s.last).Seconds(), maxElapsed)
+	s.last = now
+
+	for ; elapsed > 0; elapsed -= maxStep {
+		s.step(math.Min(elapsed, maxStep))
+	}
+}
+
+// step moves the simulation forward by `dt` seconds
+func (s *Simulator) step(dt float64) {
+	// the fan removes up to 60% & the pump up to 10% of the heat load
+	fan, pump := s.FanSpeed/maxFanRPM, s.PumpSpeed/maxPumpRPM
+	equilibrium := ambientTemp + heatLoad*(1-0.6*fan-0.1*pump)
+	s.Temperature = approach(s.Temperature, equilibrium, dt, thermalTau)
+
+	targetFan, targetPump := s.targetSpeeds()
+	s.FanSpeed = approach(s.FanSpeed, targetFan, dt, rotorTau)
+	s.PumpSpeed = approach(s.PumpSpeed, targetPump, dt, rotorTau)
+}
+
+// approach moves `value` exponentially towards `target` over `dt` seconds with time constant `tau`
+func approach(value, target, dt, tau float64) float64 {
+	return target + (value-target)*math.Exp(-dt/tau)
+}
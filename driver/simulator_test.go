@@ -0,0 +1,149 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"context"
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newClockedSimulator returns a Simulator & a function advancing its clock
+func newClockedSimulator() (*Simulator, func(time.Duration)) {
+	now := time.Date(2019, 11, 20, 18, 0, 0, 0, time.UTC)
+	sim := NewSimulator()
+	sim.Now = func() time.Time { return now }
+	sim.last = now
+
+	return sim, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestSimulatorStatus(t *testing.T) {
+	sim, _ := newClockedSimulator()
+	kraken := NewKrakenDriverWithTransport(sim)
+
+	status, err := kraken.GetStatus()
+
+	assert.Nil(t, err)
+	assert.Equal(t, 30.0, status.LiquidTemperature)
+	assert.Equal(t, 500, status.FanSpeed)
+	assert.Equal(t, 1680, status.PumpSpeed)
+	assert.Equal(t, FirmwareVersion{6, 0, 2}, status.FirmwareVersion)
+}
+
+func TestSimulatorSetSpeed(t *testing.T) {
+	sim, tick := newClockedSimulator()
+	kraken := NewKrakenDriverWithTransport(sim)
+
+	require.Nil(t, kraken.SetSpeed("fan", "20 25  35 25  50 55  60 100"))
+	require.Nil(t, kraken.SetSpeed("pump", "20 100"))
+
+	assert.Len(t, sim.Curves["fan"], 21)
+	assert.Equal(t, []int{36, 27}, sim.Curves["fan"][8])
+	assert.NotContains(t, sim.Duties, "fan")
+	assert.Equal(t, 25, sim.Duty("fan"))
+	assert.Equal(t, 100, sim.Duty("pump"))
+
+	tick(20 * time.Second)
+	status, err := kraken.GetStatus()
+
+	assert.Nil(t, err)
+	assert.InDelta(t, 500, status.FanSpeed, 1)
+	assert.InDelta(t, 2800, status.PumpSpeed, 5)
+}
+
+func TestSimulatorInstantSpeed(t *testing.T) {
+	sim, tick := newClockedSimulator()
+	sim.FirmwareVersion = FirmwareVersion{2, 1, 0}
+	sim.Temperature = 36
+	kraken := NewKrakenDriverWithTransport(sim)
+
+	require.Nil(t, kraken.SetSpeed("fan", "20 25"))
+	require.Nil(t, kraken.SetFixedSpeed("fan", "100"))
+
+	assert.NotContains(t, sim.Curves, "fan")
+	assert.Equal(t, 100, sim.Duty("fan"))
+
+	before := sim.Temperature
+	tick(time.Minute)
+	status, err := kraken.GetStatus()
+
+	assert.Nil(t, err)
+	assert.InDelta(t, 2000, status.FanSpeed, 1)
+	assert.True(t, status.LiquidTemperature < before)
+}
+
+func TestSimulatorTemperatureFollowsFan(t *testing.T) {
+	slow, tickSlow := newClockedSimulator()
+	fast, tickFast := newClockedSimulator()
+	require.Nil(t, NewKrakenDriverWithTransport(fast).SetSpeed("fan", "20 100"))
+
+	tickSlow(10 * time.Minute)
+	tickFast(10 * time.Minute)
+	slowStatus, _ := NewKrakenDriverWithTransport(slow).GetStatus()
+	fastStatus, _ := NewKrakenDriverWithTransport(fast).GetStatus()
+
+	assert.True(t, fastStatus.LiquidTemperature < slowStatus.LiquidTemperature)
+	assert.InDelta(t, 30, fastStatus.LiquidTemperature, 1)
+	assert.InDelta(t, 37, slowStatus.LiquidTemperature, 1)
+}
+
+func TestSimulatorSetColor(t *testing.T) {
+	sim := NewSimulator()
+	kraken := NewKrakenDriverWithTransport(sim)
+
+	require.Nil(t, kraken.SetColor("ring", "backwards-marquee-4", "normal", []string{"00ff00"}))
+	require.Nil(t, kraken.SetColor("logo", "fading", "normal", []string{"ff0000", "0000ff"}))
+
+	ring := sim.Lighting["ring"]
+	assert.Equal(t, byte(0x03), ring.Mode)
+	assert.Equal(t, byte(0x10), ring.Reverse)
+	assert.Equal(t, byte(0x08), ring.Modifier)
+	assert.Equal(t, byte(0x02), ring.Speed)
+	assert.Len(t, ring.Steps, 1)
+	assert.Equal(t, color.RGBA{G: 0xff, A: 1}, ring.Steps[0][0])
+	assert.Equal(t, color.RGBA{G: 0xff, A: 1}, ring.Steps[0][8])
+
+	logo := sim.Lighting["logo"]
+	assert.Equal(t, byte(0x01), logo.Mode)
+	assert.Len(t, logo.Steps, 2)
+	assert.Equal(t, color.RGBA{R: 0xff, A: 1}, logo.Steps[0][0])
+	assert.Equal(t, color.RGBA{B: 0xff, A: 1}, logo.Steps[1][0])
+}
+
+func TestSimulatorMalformedReport(t *testing.T) {
+	sim := NewSimulator()
+
+	_, err := sim.Write([]byte{0x2, 0x4d})
+	assert.Error(t, err)
+
+	report := make([]byte, writeLength)
+	report[0], report[1] = 0x2, 0x99
+	_, err = sim.Write(report)
+	assert.Error(t, err)
+}
+
+func TestSimulatorReadCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewSimulator().ReadContext(ctx, make([]byte, readLength))
+
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestSimulatorBackend(t *testing.T) {
+	kraken := NewKrakenDriver()
+	kraken.Backend = "simulator"
+	kraken.Selector = DeviceSelector{SerialNumber: "SIMULATED", Index: -1}
+
+	require.Nil(t, kraken.Connect())
+	assert.Equal(t, SimulatorDevice, kraken.Device)
+	assert.Nil(t, kraken.Close())
+}
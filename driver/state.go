@@ -0,0 +1,155 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	// SpeedProfileMethod marks speed profiles uploaded to the device
+	SpeedProfileMethod = "profile"
+	// SpeedFixedMethod marks fixed duties uploaded as a flat profile
+	SpeedFixedMethod = "fixed"
+	// SpeedInstantMethod marks fixed duties set instantly, which the device does not keep
+	SpeedInstantMethod = "instant"
+)
+
+// AppliedSpeed is the speed setting last applied to a channel
+type AppliedSpeed struct {
+	Time    time.Time    `json:"time" yaml:"time"`
+	Method  string       `json:"method" yaml:"method"`                       // SpeedProfileMethod, SpeedFixedMethod or SpeedInstantMethod
+	Duty    int          `json:"duty,omitempty" yaml:"duty,omitempty"`       // fixed or instant duty, after clamping
+	Profile SpeedProfile `json:"profile,omitempty" yaml:"profile,omitempty"` // as requested
+	Points  SpeedProfile `json:"points,omitempty" yaml:"points,omitempty"`   // normalized, interpolated & clamped, as stored by the device
+}
+
+// AppliedColor is the lighting last applied to a channel
+type AppliedColor struct {
+	Time    time.Time `json:"time" yaml:"time"`
+	Channel string    `json:"channel" yaml:"channel"` // the channel requested, sync for both logo & ring
+	Mode    string    `json:"mode" yaml:"mode"`
+	Speed   string    `json:"speed,omitempty" yaml:"speed,omitempty"` // animation speed
+	Colors  []string  `json:"colors,omitempty" yaml:"colors,omitempty"`
+}
+
+// DeviceState is everything applied to a device, as the device can't report it back
+type DeviceState struct {
+	Speed map[string]AppliedSpeed `json:"speed,omitempty" yaml:"speed,omitempty"` // per speed channel
+	Color map[string]AppliedColor `json:"color,omitempty" yaml:"color,omitempty"` // per logo & ring
+}
+
+// State is the applied state of all devices, keyed by serial number
+type State struct {
+	Devices map[string]*DeviceState `json:"devices"`
+}
+
+// LoadState reads the state file at `path`, a missing file is an empty state
+func LoadState(path string) (*State, error) {
+	s := &State{Devices: map[string]*DeviceState{}}
+
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, fmt.Errorf("reading state file %s failed: %w", path, err)
+	}
+	if s.Devices == nil {
+		s.Devices = map[string]*DeviceState{}
+	}
+
+	return s, nil
+}
+
+// Save writes the state file at `path`, replacing it atomically
+func (s *State) Save(path string) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(b, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Device returns the state of the device with `serial`, adding it if it is not known yet
+func (s *State) Device(serial string) *DeviceState {
+	d, ok := s.Devices[serial]
+	if !ok {
+		d = &DeviceState{}
+		s.Devices[serial] = d
+	}
+
+	return d
+}
+
+// Serials lists the serial numbers of all devices with a state, sorted
+func (s *State) Serials() []string {
+	var serials []string
+	for serial := range s.Devices {
+		serials = append(serials, serial)
+	}
+	sort.Strings(serials)
+
+	return serials
+}
+
+// recordSpeed remembers `speed` for `channel`, a nil state remembers nothing
+func (d *DeviceState) recordSpeed(channel string, speed AppliedSpeed) {
+	if d == nil {
+		return
+	}
+
+	if d.Speed == nil {
+		d.Speed = map[string]AppliedSpeed{}
+	}
+	speed.Time = time.Now()
+	d.Speed[channel] = speed
+}
+
+// recordColor remembers `mode`, `speed` & `colors` for `channel`, for both logo & ring if it is sync
+func (d *DeviceState) recordColor(channel, mode, speed string, colors []string) {
+	if d == nil {
+		return
+	}
+
+	if d.Color == nil {
+		d.Color = map[string]AppliedColor{}
+	}
+	applied := AppliedColor{Time: time.Now(), Channel: channel, Mode: mode, Speed: speed, Colors: colors}
+
+	if channel == "sync" {
+		d.Color["logo"], d.Color["ring"] = applied, applied
+	} else {
+		d.Color[channel] = applied
+	}
+}
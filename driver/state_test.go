@@ -0,0 +1,80 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadStateMissing(t *testing.T) {
+	state, err := LoadState(filepath.Join(os.TempDir(), "coolctl-missing", "state.json"))
+
+	require.Nil(t, err)
+	assert.Empty(t, state.Devices)
+}
+
+func TestStateSaveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "coolctl-state")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "coolctl", "state.json")
+
+	state, err := LoadState(path)
+	require.Nil(t, err)
+	state.Device("61A4A2C3B052").recordColor("ring", "fading", "slower", []string{"FF0000", "00FF00"})
+	require.Nil(t, state.Save(path))
+
+	loaded, err := LoadState(path)
+
+	require.Nil(t, err)
+	assert.Equal(t, []string{"61A4A2C3B052"}, loaded.Serials())
+	assert.Equal(t, "fading", loaded.Devices["61A4A2C3B052"].Color["ring"].Mode)
+	assert.Equal(t, "slower", loaded.Devices["61A4A2C3B052"].Color["ring"].Speed)
+	assert.Equal(t, []string{"FF0000", "00FF00"}, loaded.Devices["61A4A2C3B052"].Color["ring"].Colors)
+}
+
+func TestStateRecordsSpeed(t *testing.T) {
+	kraken := NewKrakenDriverWithTransport(NewMemoryTransport(statusReport()))
+	kraken.State = &DeviceState{}
+
+	require.Nil(t, kraken.SetSpeed("fan", "20 25  35 25  50 55  60 100"))
+	require.Nil(t, kraken.SetFixedSpeed("pump", "70"))
+
+	fan := kraken.State.Speed["fan"]
+	assert.Equal(t, SpeedProfileMethod, fan.Method)
+	assert.Equal(t, SpeedProfile{{20, 25}, {35, 25}, {50, 55}, {60, 100}}, fan.Profile)
+	assert.Len(t, fan.Points, curvePoints)
+	assert.Equal(t, []int{20, 25}, fan.Points[0])
+	assert.False(t, fan.Time.IsZero())
+
+	pump := kraken.State.Speed["pump"]
+	assert.Equal(t, SpeedFixedMethod, pump.Method)
+	assert.Equal(t, 70, pump.Duty)
+	assert.Equal(t, []int{58, 70}, pump.Points[curvePoints-2])
+}
+
+func TestStateRecordsColorSync(t *testing.T) {
+	kraken := NewKrakenDriverWithTransport(NewMemoryTransport())
+	kraken.State = &DeviceState{}
+
+	require.Nil(t, kraken.SetColor("sync", "fixed", "normal", []string{"FF0000"}))
+
+	assert.Equal(t, "sync", kraken.State.Color["logo"].Channel)
+	assert.Equal(t, "fixed", kraken.State.Color["ring"].Mode)
+}
+
+func TestStateNotRecordedOnError(t *testing.T) {
+	kraken := NewKrakenDriverWithTransport(NewMemoryTransport())
+	kraken.State = &DeviceState{}
+
+	assert.NotNil(t, kraken.SetSpeed("fan", "20"))
+	assert.Empty(t, kraken.State.Speed)
+}
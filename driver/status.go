@@ -0,0 +1,49 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"fmt"
+	"time"
+)
+
+// Status holds a single status report of the device
+type Status struct {
+	LiquidTemperature float64 // °C
+	FanSpeed          int     // rpm
+	PumpSpeed         int     // rpm
+	FirmwareVersion   FirmwareVersion
+	Time              time.Time
+}
+
+// FirmwareVersion is the major.minor.patch version of the device firmware
+type FirmwareVersion struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// String formats the version as major.minor.patch
+func (v FirmwareVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Compare returns -1, 0 or 1 if the version is older than, equal to or newer than `o`
+func (v FirmwareVersion) Compare(o FirmwareVersion) int {
+	for _, c := range [][2]int{{v.Major, o.Major}, {v.Minor, o.Minor}, {v.Patch, o.Patch}} {
+		if c[0] < c[1] {
+			return -1
+		} else if c[0] > c[1] {
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// AtLeast checks if the version is equal to or newer than `o`
+func (v FirmwareVersion) AtLeast(o FirmwareVersion) bool {
+	return v.Compare(o) >= 0
+}
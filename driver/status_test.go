@@ -0,0 +1,37 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFirmwareVersionString(t *testing.T) {
+	assert.Equal(t, "6.0.2", FirmwareVersion{6, 0, 2}.String())
+	assert.Equal(t, "2.256.10", FirmwareVersion{2, 256, 10}.String())
+}
+
+var compareTests = []struct {
+	a, b FirmwareVersion
+	out  int
+}{
+	{FirmwareVersion{6, 0, 2}, FirmwareVersion{6, 0, 2}, 0},
+	{FirmwareVersion{6, 0, 2}, FirmwareVersion{6, 0, 1}, 1},
+	{FirmwareVersion{6, 0, 2}, FirmwareVersion{6, 1, 0}, -1},
+	{FirmwareVersion{3, 0, 0}, FirmwareVersion{2, 9, 9}, 1},
+	{FirmwareVersion{2, 9, 9}, FirmwareVersion{3, 0, 0}, -1},
+}
+
+func TestFirmwareVersionCompare(t *testing.T) {
+	for _, tt := range compareTests {
+		t.Run(tt.a.String()+" "+tt.b.String(), func(t *testing.T) {
+			assert.Equal(t, tt.out, tt.a.Compare(tt.b))
+			assert.Equal(t, -tt.out, tt.b.Compare(tt.a))
+			assert.Equal(t, tt.out >= 0, tt.a.AtLeast(tt.b))
+		})
+	}
+}
@@ -0,0 +1,208 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	// TraceRead marks reports read from the device
+	TraceRead = "read"
+	// TraceWrite marks reports written to the device
+	TraceWrite = "write"
+)
+
+// ErrTraceMismatch is returned when a replayed session deviates from its trace
+var ErrTraceMismatch = errors.New("trace mismatch")
+
+// TraceEntry is a single report in a trace, traces are stored as one JSON entry per line
+type TraceEntry struct {
+	Time      time.Time `json:"time"`
+	Direction string    `json:"direction"`
+	Data      string    `json:"data"`            // hex
+	Error     string    `json:"error,omitempty"` // why the read or write failed
+}
+
+// RecordingTransport wraps a Transport & writes every report read or written to a trace
+type RecordingTransport struct {
+	Transport
+	w   io.Writer
+	enc *json.Encoder
+	mu  sync.Mutex
+}
+
+// NewRecordingTransport returns a RecordingTransport writing the trace of `t` to `w`
+func NewRecordingTransport(t Transport, w io.Writer) *RecordingTransport {
+	return &RecordingTransport{Transport: t, w: w, enc: json.NewEncoder(w)}
+}
+
+// ReadContext reads a report from the wrapped transport & records it, failed reads too
+func (t *RecordingTransport) ReadContext(ctx context.Context, report []byte) (int, error) {
+	n, err := t.Transport.ReadContext(ctx, report)
+	if rerr := t.record(TraceRead, report[:n], err); err == nil {
+		err = rerr
+	}
+
+	return n, err
+}
+
+// Write writes a report to the wrapped transport & records it, failed writes with the whole report
+func (t *RecordingTransport) Write(report []byte) (int, error) {
+	n, err := t.Transport.Write(report)
+
+	data := report[:n]
+	if err != nil {
+		data = report
+	}
+	if rerr := t.record(TraceWrite, data, err); err == nil {
+		err = rerr
+	}
+
+	return n, err
+}
+
+// Close closes the wrapped transport & the trace writer, if it is an io.Closer
+func (t *RecordingTransport) Close() error {
+	err := t.Transport.Close()
+
+	if c, ok := t.w.(io.Closer); ok {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+
+	return err
+}
+
+// record appends a single entry to the trace, with `ioErr` if the read or write failed
+func (t *RecordingTransport) record(direction string, data []byte, ioErr error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry := TraceEntry{Time: time.Now(), Direction: direction, Data: hex.EncodeToString(data)}
+	if ioErr != nil {
+		entry.Error = ioErr.Error()
+	}
+
+	if err := t.enc.Encode(entry); err != nil {
+		return fmt.Errorf("recording trace failed: %w", err)
+	}
+
+	return nil
+}
+
+// ReplayTransport is a Transport that serves the reads of a trace & checks that writes match it, failing
+// where the recorded read or write failed
+type ReplayTransport struct {
+	Entries []TraceEntry
+	Closed  bool
+
+	pos int
+}
+
+// NewReplayTransport reads a trace from `r` & returns a ReplayTransport for it
+func NewReplayTransport(r io.Reader) (*ReplayTransport, error) {
+	t := &ReplayTransport{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var entry TraceEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("trace line %d: %w", line, err)
+		}
+
+		if entry.Direction != TraceRead && entry.Direction != TraceWrite {
+			return nil, fmt.Errorf("trace line %d: unknown direction %q", line, entry.Direction)
+		}
+
+		if _, err := hex.DecodeString(entry.Data); err != nil {
+			return nil, fmt.Errorf("trace line %d: %w", line, err)
+		}
+
+		t.Entries = append(t.Entries, entry)
+	}
+
+	return t, scanner.Err()
+}
+
+// Remaining returns the number of entries not replayed yet
+func (t *ReplayTransport) Remaining() int {
+	return len(t.Entries) - t.pos
+}
+
+// ReadContext copies the next report of the trace into `report`, it must be a read
+func (t *ReplayTransport) ReadContext(ctx context.Context, report []byte) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	entry, err := t.next(TraceRead)
+	if err != nil {
+		return 0, err
+	}
+
+	data, _ := hex.DecodeString(entry.Data)
+	n := copy(report, data)
+	if entry.Error != "" {
+		return n, errors.New(entry.Error)
+	}
+
+	return n, nil
+}
+
+// Write checks that `report` is the next report of the trace
+func (t *ReplayTransport) Write(report []byte) (int, error) {
+	entry, err := t.next(TraceWrite)
+	if err != nil {
+		return 0, err
+	}
+
+	if got := hex.EncodeToString(report); got != entry.Data {
+		return 0, fmt.Errorf("%w: entry %d: expected write %s, got %s", ErrTraceMismatch, t.pos, entry.Data, got)
+	}
+
+	if entry.Error != "" {
+		return 0, errors.New(entry.Error)
+	}
+
+	return len(report), nil
+}
+
+// Close marks the transport as closed
+func (t *ReplayTransport) Close() error {
+	t.Closed = true
+
+	return nil
+}
+
+// next returns the next entry of the trace, which must go in `direction`
+func (t *ReplayTransport) next(direction string) (TraceEntry, error) {
+	if t.pos >= len(t.Entries) {
+		return TraceEntry{}, fmt.Errorf("%w: unexpected %s after the end of the trace", ErrTraceMismatch, direction)
+	}
+
+	entry := t.Entries[t.pos]
+	t.pos++
+
+	if entry.Direction != direction {
+		return TraceEntry{}, fmt.Errorf("%w: entry %d: expected %s, got %s", ErrTraceMismatch, t.pos, entry.Direction, direction)
+	}
+
+	return entry, nil
+}
@@ -0,0 +1,156 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordingTransport(t *testing.T) {
+	var trace bytes.Buffer
+	memory := NewMemoryTransport([]byte{0x04, 0x20})
+	transport := NewRecordingTransport(memory, &trace)
+
+	_, err := transport.Write([]byte{0x02, 0x4d})
+	require.Nil(t, err)
+	_, err = transport.ReadContext(context.Background(), make([]byte, 2))
+	require.Nil(t, err)
+	require.Nil(t, transport.Close())
+
+	assert.True(t, memory.Closed)
+	assert.Equal(t, [][]byte{{0x02, 0x4d}}, memory.Writes)
+
+	replay, err := NewReplayTransport(&trace)
+	require.Nil(t, err)
+	require.Len(t, replay.Entries, 2)
+	assert.Equal(t, TraceWrite, replay.Entries[0].Direction)
+	assert.Equal(t, "024d", replay.Entries[0].Data)
+	assert.Equal(t, TraceRead, replay.Entries[1].Direction)
+	assert.Equal(t, "0420", replay.Entries[1].Data)
+	assert.False(t, replay.Entries[0].Time.IsZero())
+}
+
+// failingTransport is a MemoryTransport whose writes fail with `err`
+type failingTransport struct {
+	*MemoryTransport
+	err error
+}
+
+// Write fails with the transport's error
+func (t *failingTransport) Write(report []byte) (int, error) {
+	return 0, t.err
+}
+
+func TestRecordingTransportErrors(t *testing.T) {
+	var trace bytes.Buffer
+	transport := NewRecordingTransport(&failingTransport{NewMemoryTransport(), errors.New("pipe error")}, &trace)
+
+	_, err := transport.Write([]byte{0x02, 0x4d})
+	assert.EqualError(t, err, "pipe error")
+	_, err = transport.ReadContext(context.Background(), make([]byte, 2))
+	assert.True(t, errors.Is(err, io.EOF))
+
+	replay, err := NewReplayTransport(&trace)
+	require.Nil(t, err)
+	require.Len(t, replay.Entries, 2)
+	assert.Equal(t, TraceWrite, replay.Entries[0].Direction)
+	assert.Equal(t, "024d", replay.Entries[0].Data)
+	assert.Equal(t, "pipe error", replay.Entries[0].Error)
+	assert.Equal(t, TraceRead, replay.Entries[1].Direction)
+	assert.Equal(t, "", replay.Entries[1].Data)
+	assert.Equal(t, "no queued report: EOF", replay.Entries[1].Error)
+
+	// the replay fails just like the recorded session
+	_, err = replay.Write([]byte{0x02, 0x4d})
+	assert.EqualError(t, err, "pipe error")
+	_, err = replay.ReadContext(context.Background(), make([]byte, 2))
+	assert.EqualError(t, err, "no queued report: EOF")
+}
+
+const replayTrace = `{"time":"2019-11-20T18:32:10.000+01:00","direction":"write","data":"024d"}
+{"time":"2019-11-20T18:32:10.003+01:00","direction":"read","data":"0420"}
+`
+
+func TestReplayTransport(t *testing.T) {
+	transport, err := NewReplayTransport(strings.NewReader(replayTrace))
+	require.Nil(t, err)
+
+	_, err = transport.Write([]byte{0x02, 0x4d})
+	assert.Nil(t, err)
+
+	report := make([]byte, 2)
+	_, err = transport.ReadContext(context.Background(), report)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x04, 0x20}, report)
+	assert.Equal(t, 0, transport.Remaining())
+
+	_, err = transport.Write([]byte{0x02, 0x4d})
+	assert.True(t, errors.Is(err, ErrTraceMismatch))
+}
+
+func TestReplayTransportMismatch(t *testing.T) {
+	transport, err := NewReplayTransport(strings.NewReader(replayTrace))
+	require.Nil(t, err)
+	_, err = transport.Write([]byte{0x02, 0x4c})
+	assert.True(t, errors.Is(err, ErrTraceMismatch))
+
+	transport, err = NewReplayTransport(strings.NewReader(replayTrace))
+	require.Nil(t, err)
+	_, err = transport.ReadContext(context.Background(), make([]byte, 2))
+	assert.True(t, errors.Is(err, ErrTraceMismatch))
+}
+
+var invalidTraces = []string{
+	`{"direction":"write","data":"024d"`,
+	`{"direction":"sideways","data":"024d"}`,
+	`{"direction":"write","data":"xyz"}`,
+}
+
+func TestReplayTransportInvalid(t *testing.T) {
+	for _, trace := range invalidTraces {
+		t.Run(trace, func(t *testing.T) {
+			_, err := NewReplayTransport(strings.NewReader(trace))
+
+			assert.Error(t, err)
+		})
+	}
+}
+
+// replaySession replays the trace in testdata/`name` against the driver calls in `session`
+func replaySession(t *testing.T, name string, session func(*KrakenDriver) error) {
+	file, err := os.Open("testdata/" + name)
+	require.Nil(t, err)
+	defer file.Close()
+
+	transport, err := NewReplayTransport(file)
+	require.Nil(t, err)
+
+	assert.Nil(t, session(NewKrakenDriverWithTransport(transport)))
+	assert.Equal(t, 0, transport.Remaining())
+}
+
+func TestReplaySessions(t *testing.T) {
+	replaySession(t, "color-ring-fading.jsonl", func(d *KrakenDriver) error {
+		return d.SetColor("ring", "fading", "normal", []string{"ff0000", "00ff00", "0000ff"})
+	})
+	replaySession(t, "color-logo-fixed.jsonl", func(d *KrakenDriver) error {
+		return d.SetColor("logo", "fixed", "normal", []string{"ff8000"})
+	})
+	replaySession(t, "speed-fan.jsonl", func(d *KrakenDriver) error {
+		return d.SetSpeed("fan", "20 25  35 25  50 55  60 100")
+	})
+	replaySession(t, "fixed-speed-pump.jsonl", func(d *KrakenDriver) error {
+		return d.SetFixedSpeed("pump", "70")
+	})
+}
@@ -0,0 +1,63 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// Transport moves raw reports between the driver and a device
+type Transport interface {
+	// ReadContext reads a single 64-byte report
+	ReadContext(ctx context.Context, report []byte) (int, error)
+	// Write writes a single 65-byte report
+	Write(report []byte) (int, error)
+	// Close releases the device
+	Close() error
+}
+
+// MemoryTransport is an in-memory Transport that serves queued reports & records written ones
+type MemoryTransport struct {
+	Reads  [][]byte
+	Writes [][]byte
+	Closed bool
+}
+
+// NewMemoryTransport returns a new MemoryTransport serving `reads` in order
+func NewMemoryTransport(reads ...[]byte) *MemoryTransport {
+	return &MemoryTransport{Reads: reads}
+}
+
+// ReadContext copies the next queued report into `report`
+func (t *MemoryTransport) ReadContext(ctx context.Context, report []byte) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if len(t.Reads) == 0 {
+		return 0, fmt.Errorf("no queued report: %w", io.EOF)
+	}
+
+	n := copy(report, t.Reads[0])
+	t.Reads = t.Reads[1:]
+
+	return n, nil
+}
+
+// Write records a copy of `report`
+func (t *MemoryTransport) Write(report []byte) (int, error) {
+	t.Writes = append(t.Writes, append([]byte(nil), report...))
+
+	return len(report), nil
+}
+
+// Close marks the transport as closed
+func (t *MemoryTransport) Close() error {
+	t.Closed = true
+
+	return nil
+}
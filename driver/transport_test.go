@@ -0,0 +1,62 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryTransportRead(t *testing.T) {
+	transport := NewMemoryTransport([]byte{1, 2, 3}, []byte{4, 5, 6})
+
+	report := make([]byte, 3)
+	n, err := transport.ReadContext(context.Background(), report)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, []byte{1, 2, 3}, report)
+
+	n, err = transport.ReadContext(context.Background(), report)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, []byte{4, 5, 6}, report)
+
+	_, err = transport.ReadContext(context.Background(), report)
+	assert.True(t, errors.Is(err, io.EOF))
+}
+
+func TestMemoryTransportReadCanceled(t *testing.T) {
+	transport := NewMemoryTransport([]byte{1, 2, 3})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := transport.ReadContext(ctx, make([]byte, 3))
+
+	assert.Equal(t, context.Canceled, err)
+	assert.Len(t, transport.Reads, 1)
+}
+
+func TestMemoryTransportWrite(t *testing.T) {
+	transport := NewMemoryTransport()
+	report := []byte{1, 2, 3}
+
+	n, err := transport.Write(report)
+	report[0] = 9
+
+	assert.Nil(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, [][]byte{{1, 2, 3}}, transport.Writes)
+}
+
+func TestMemoryTransportClose(t *testing.T) {
+	transport := NewMemoryTransport()
+
+	assert.Nil(t, transport.Close())
+	assert.True(t, transport.Closed)
+}
@@ -0,0 +1,218 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// coolctl is a Golang-Port of liquidctl.
+// Copyright (C) 2018–2019 Jonas Malaco
+// Copyright (C) 2018–2019 each contribution's author
+
+//go:build cgo
+// +build cgo
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/gousb"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultBackend is the backend used unless another one is requested
+	DefaultBackend = "usb"
+
+	readEndpoint  = 1
+	writeEndpoint = 1
+)
+
+var (
+	config    = 1
+	iface     = 0
+	alternate = 0
+	bufSize   = 0
+)
+
+type contextReader interface {
+	ReadContext(context.Context, []byte) (int, error)
+}
+
+func init() {
+	backends["usb"] = usbBackend{}
+}
+
+// usbBackend opens devices through libusb, detaching the kernel driver
+type usbBackend struct{}
+
+// Devices lists all connected devices matching `vid` & `pid`
+func (usbBackend) Devices(vid, pid uint16) ([]DeviceInfo, error) {
+	ctx, err := newUSBContext()
+	if err != nil {
+		return nil, err
+	}
+	defer ctx.Close()
+	ctx.Debug(Debug)
+
+	devs, err := ctx.OpenDevices(func(desc *gousb.DeviceDesc) bool {
+		return desc.Vendor == gousb.ID(vid) && desc.Product == gousb.ID(pid)
+	})
+	if err != nil && len(devs) == 0 {
+		return nil, err
+	} else if err != nil {
+		log.Warnf("some devices could not be opened: %v", err)
+	}
+
+	paths := usbPortPaths()
+
+	var infos []DeviceInfo
+	for _, dev := range devs {
+		infos = append(infos, usbDeviceInfo(dev, paths[usbAddress{dev.Desc.Bus, dev.Desc.Address}]))
+		dev.Close()
+	}
+
+	return infos, nil
+}
+
+// Open opens the device described by `info`
+func (usbBackend) Open(info DeviceInfo) (Transport, error) {
+	ctx, err := newUSBContext()
+	if err != nil {
+		return nil, err
+	}
+	ctx.Debug(Debug)
+
+	t := &usbTransport{ctx: ctx}
+
+	devs, err := ctx.OpenDevices(func(desc *gousb.DeviceDesc) bool {
+		return desc.Bus == info.Bus && desc.Address == info.Address
+	})
+	if len(devs) == 0 {
+		t.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDeviceNotFound, err)
+		}
+		return nil, ErrDeviceNotFound
+	}
+	t.dev = devs[0]
+
+	if err := t.claim(); err != nil {
+		t.Close()
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// usbDeviceInfo describes an open device at `portPath`, string descriptors that can't be read are left empty
+func usbDeviceInfo(dev *gousb.Device, portPath string) DeviceInfo {
+	manufacturer, _ := dev.Manufacturer()
+	product, _ := dev.Product()
+	serial, _ := dev.SerialNumber()
+
+	return DeviceInfo{
+		Backend:      "usb",
+		Bus:          dev.Desc.Bus,
+		PortPath:     portPath,
+		Address:      dev.Desc.Address,
+		Manufacturer: manufacturer,
+		Product:      product,
+		SerialNumber: serial,
+	}
+}
+
+// usbTransport talks to the device through libusb
+type usbTransport struct {
+	ctx  *gousb.Context
+	dev  *gousb.Device
+	cfg  *gousb.Config
+	intf *gousb.Interface
+	in   *gousb.InEndpoint
+	out  *gousb.OutEndpoint
+}
+
+// newUSBContext creates a new USB Context, turning libusb initialization panics into errors
+func newUSBContext() (ctx *gousb.Context, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("initializing libusb failed: %v", r)
+		}
+	}()
+
+	return gousb.NewContext(), nil
+}
+
+// claim detaches the kernel driver & claims the interface and its endpoints
+func (t *usbTransport) claim() error {
+	err := t.dev.SetAutoDetach(true)
+	if err != nil {
+		return err
+	}
+
+	t.cfg, err = t.dev.Config(config)
+	if err != nil {
+		return fmt.Errorf("dev.Config(%d): %w", config, err)
+	}
+
+	t.intf, err = t.cfg.Interface(iface, alternate)
+	if err != nil {
+		return fmt.Errorf("cfg.Interface(%d, %d): %w", iface, alternate, err)
+	}
+
+	t.in, err = t.intf.InEndpoint(readEndpoint)
+	if err != nil {
+		return fmt.Errorf("dev.InEndpoint(): %w", err)
+	}
+
+	t.out, err = t.intf.OutEndpoint(writeEndpoint)
+	if err != nil {
+		return fmt.Errorf("dev.OutEndpoint(): %w", err)
+	}
+
+	return nil
+}
+
+// ReadContext reads a single report from the in endpoint
+func (t *usbTransport) ReadContext(ctx context.Context, report []byte) (int, error) {
+	var rdr contextReader = t.in
+	if bufSize > 1 {
+		log.Print("creating buffer...")
+		s, err := t.in.NewStream(len(report), bufSize)
+		if err != nil {
+			return 0, fmt.Errorf("ep.NewStream(): %w", err)
+		}
+		defer s.Close()
+		rdr = s
+	}
+
+	return rdr.ReadContext(ctx, report)
+}
+
+// Write writes a single report to the out endpoint
+func (t *usbTransport) Write(report []byte) (int, error) {
+	return t.out.Write(report)
+}
+
+// Close releases the interface, the configuration, the device & the USB Context
+func (t *usbTransport) Close() error {
+	if t.intf != nil {
+		t.intf.Close()
+	}
+
+	var err error
+	if t.cfg != nil {
+		err = t.cfg.Close()
+	}
+
+	if t.dev != nil {
+		if derr := t.dev.Close(); err == nil {
+			err = derr
+		}
+	}
+
+	if cerr := t.ctx.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
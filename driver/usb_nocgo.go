@@ -0,0 +1,25 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+//go:build !cgo
+// +build !cgo
+
+// Package driver contains all code for controlling devices
+package driver
+
+func init() {
+	backends["usb"] = usbBackend{}
+}
+
+// usbBackend stands in for the libusb backend in builds without cgo
+type usbBackend struct{}
+
+// Devices always fails, libusb requires cgo
+func (usbBackend) Devices(vid, pid uint16) ([]DeviceInfo, error) {
+	return nil, errNoCgo
+}
+
+// Open always fails, libusb requires cgo
+func (usbBackend) Open(info DeviceInfo) (Transport, error) {
+	return nil, errNoCgo
+}
@@ -0,0 +1,15 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+//go:build !cgo && linux
+// +build !cgo,linux
+
+// Package driver contains all code for controlling devices
+package driver
+
+import "errors"
+
+// DefaultBackend is the backend used unless another one is requested
+const DefaultBackend = "hidraw"
+
+var errNoCgo = errors.New("the usb backend requires a build with cgo, try --backend hidraw")
@@ -0,0 +1,53 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+//go:build cgo
+// +build cgo
+
+// Package driver contains all code for controlling devices
+package driver
+
+// #cgo pkg-config: libusb-1.0
+// #include <libusb.h>
+import "C"
+
+import "unsafe"
+
+// usbAddress identifies a device by bus & address
+type usbAddress struct {
+	bus, address int
+}
+
+// usbPortPaths returns the port path of every connected device, as gousb only tells the last port number
+func usbPortPaths() map[usbAddress]string {
+	var ctx *C.libusb_context
+	if C.libusb_init(&ctx) != 0 {
+		return nil
+	}
+	defer C.libusb_exit(ctx)
+
+	var list **C.libusb_device
+	n := C.libusb_get_device_list(ctx, &list)
+	if n < 0 {
+		return nil
+	}
+	defer C.libusb_free_device_list(list, 1)
+
+	paths := map[usbAddress]string{}
+	for _, dev := range (*[1 << 20]*C.libusb_device)(unsafe.Pointer(list))[:n:n] {
+		// USB 3 allows hubs 7 levels deep
+		var ports [7]C.uint8_t
+		count := C.libusb_get_port_numbers(dev, &ports[0], C.int(len(ports)))
+		if count < 0 {
+			continue
+		}
+
+		path := make([]int, count)
+		for i := range path {
+			path[i] = int(ports[i])
+		}
+		paths[usbAddress{int(C.libusb_get_bus_number(dev)), int(C.libusb_get_device_address(dev))}] = formatPortPath(path)
+	}
+
+	return paths
+}
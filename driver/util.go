@@ -10,11 +10,14 @@ package driver
 
 import (
 	"encoding/hex"
+	"fmt"
 	"image/color"
 	"math"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"unicode"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -23,6 +26,13 @@ import (
 type SpeedProfile [][]int
 
 func colorFromHexString(c string) (*color.RGBA, error) {
+	if len(c) == 3 {
+		c = string([]byte{c[0], c[0], c[1], c[1], c[2], c[2]})
+	}
+	if len(c) != 6 {
+		return nil, fmt.Errorf("hex colors have 3 or 6 digits, not %d", len(c))
+	}
+
 	b, err := hex.DecodeString(c)
 	if err != nil {
 		return nil, err
@@ -35,7 +45,7 @@ func paletteFromColors(colors []string) (*color.Palette, error) {
 	var palette color.Palette
 	if colors != nil {
 		for _, c := range colors {
-			colorCode, err := colorFromHexString(c)
+			colorCode, err := ParseColor(c)
 			if err != nil {
 				return nil, err
 			}
@@ -46,9 +56,9 @@ func paletteFromColors(colors []string) (*color.Palette, error) {
 	return &palette, nil
 }
 
-func generateSteps(colors color.Palette, mincolors, maxcolors int, mode string, ringonly int) []color.Palette {
+func generateSteps(colors color.Palette, mincolors, maxcolors int, mode string, ringonly int) ([]color.Palette, error) {
 	if len(colors) < mincolors {
-		log.Fatalf("not enough colors for mode %s, at least %d required", mode, mincolors)
+		return nil, fmt.Errorf("%w for mode %s, at least %d required", ErrNotEnoughColors, mode, mincolors)
 	} else if maxcolors == 0 {
 		if len(colors) > 0 {
 			log.Printf("too many colors for mode %s, none needed", mode)
@@ -74,13 +84,13 @@ func generateSteps(colors color.Palette, mincolors, maxcolors int, mode string,
 			steps = append(steps, colorPalette)
 		}
 	} else if ringonly == 1 {
-		steps = append(steps, color.Palette{color.RGBA{A: 1}})
-		steps = append(steps, colors)
+		// the logo stays dark, the colors are the ring's
+		steps = append(steps, append(color.Palette{color.RGBA{A: 1}}, colors...))
 	} else {
 		steps = append(steps, colors)
 	}
 
-	return steps
+	return steps, nil
 }
 
 func makeRange(min, max, steps int) []int {
@@ -93,30 +103,112 @@ func makeRange(min, max, steps int) []int {
 	return a
 }
 
-func parseProfile(s string) SpeedProfile {
-	d, profiles := SpeedProfile{}, strings.Split(s, "  ")
+// profileColons matches a colon between temperature & duty, with any whitespace around it
+var profileColons = regexp.MustCompile(`\s*:\s*`)
 
-	for _, profile := range profiles {
-		p := strings.Split(profile, " ")
+// parseProfile parses temperature & duty pairs, either as a flat list (20 25  35 25, like liquidctl)
+// or joined by colons (20:25,35:25). Temperatures may end in °C, C or F, duties in %.
+func parseProfile(s string) (SpeedProfile, error) {
+	fields := strings.FieldsFunc(profileColons.ReplaceAllString(s, ":"), func(r rune) bool {
+		return unicode.IsSpace(r) || r == ',' || r == ';'
+	})
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("%w: please provide temperature & duty pairs (e.g: 20 25  35 25 or 20:25,35:25)", ErrInvalidProfile)
+	}
 
-		if len(p) < 2 {
-			log.Fatal("please provide a temperature & duty speed")
+	var values []string
+	for _, field := range fields {
+		pair := strings.Split(field, ":")
+		switch {
+		case len(pair) == 1:
+			values = append(values, field)
+		case len(pair) != 2 || pair[0] == "" || pair[1] == "":
+			return nil, fmt.Errorf("%w: %q is not a temperature:duty pair", ErrInvalidProfile, field)
+		case len(values)%2 != 0:
+			return nil, fmt.Errorf("%w: temperature %s has no duty before %s", ErrInvalidProfile, values[len(values)-1], field)
+		default:
+			values = append(values, pair...)
 		}
+	}
 
-		temp, err := strconv.Atoi(p[0])
+	if len(values)%2 != 0 {
+		return nil, fmt.Errorf("%w: odd number of values (%d), temperature %s has no duty", ErrInvalidProfile, len(values), values[len(values)-1])
+	}
+
+	d := SpeedProfile{}
+	for i := 0; i < len(values); i += 2 {
+		temp, err := parseProfileTemperature(values[i])
 		if err != nil {
-			log.Fatal(err)
+			return nil, err
 		}
 
-		duty, err := strconv.Atoi(p[1])
+		duty, err := parseProfileDuty(values[i+1])
 		if err != nil {
-			log.Fatal(err)
+			return nil, err
 		}
 
 		d = append(d, []int{temp, duty})
 	}
 
-	return d
+	return d, nil
+}
+
+// parseProfileTemperature parses a temperature in °C, or in °F if it ends in F
+func parseProfileTemperature(v string) (int, error) {
+	number, unit := splitUnit(v)
+	temp, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: temperature %q is not a number (e.g: 35, 35°C or 95F)", ErrInvalidProfile, v)
+	}
+
+	switch strings.ToUpper(unit) {
+	case "", "C", "°C", "℃":
+		if temp != math.Trunc(temp) {
+			return 0, fmt.Errorf("%w: temperature %q is not a whole number of °C", ErrInvalidProfile, v)
+		}
+	case "F", "°F", "℉":
+		temp = (temp - 32) * 5 / 9
+	case "%":
+		return 0, fmt.Errorf("%w: %q is a duty where a temperature is expected, pairs start with the temperature", ErrInvalidProfile, v)
+	default:
+		return 0, fmt.Errorf("%w: temperature %q has an unknown unit %q (e.g: °C, C or F)", ErrInvalidProfile, v, unit)
+	}
+
+	return int(math.Round(temp)), nil
+}
+
+// parseProfileDuty parses a duty in %
+func parseProfileDuty(v string) (int, error) {
+	number, unit := splitUnit(v)
+	duty, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: duty %q is not a number (e.g: 25 or 25%%)", ErrInvalidProfile, v)
+	}
+
+	switch strings.ToUpper(unit) {
+	case "", "%":
+		if duty != math.Trunc(duty) {
+			return 0, fmt.Errorf("%w: duty %q is not a whole number of %%", ErrInvalidProfile, v)
+		}
+	case "C", "°C", "℃", "F", "°F", "℉":
+		return 0, fmt.Errorf("%w: %q is a temperature where a duty is expected, pairs end with the duty", ErrInvalidProfile, v)
+	default:
+		return 0, fmt.Errorf("%w: duty %q has an unknown unit %q (e.g: %%)", ErrInvalidProfile, v, unit)
+	}
+
+	return int(duty), nil
+}
+
+// splitUnit splits `v` into its leading number & the unit following it, e.g. 95 & °F
+func splitUnit(v string) (string, string) {
+	i := strings.IndexFunc(v, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '.' && r != '-' && r != '+'
+	})
+	if i < 0 {
+		return v, ""
+	}
+
+	return v[:i], v[i:]
 }
 
 func normalizeProfile(p SpeedProfile, temp int) SpeedProfile {
@@ -136,10 +228,10 @@ func normalizeProfile(p SpeedProfile, temp int) SpeedProfile {
 	return p
 }
 
-func interpolateProfile(p SpeedProfile) SpeedProfile {
+func interpolateProfile(p SpeedProfile, grid []int) SpeedProfile {
 	newProfile, duty, lower, upper := SpeedProfile{}, 0, p[0], p[len(p)-1]
 
-	for _, stdtemp := range makeRange(20, 62, 2) {
+	for _, stdtemp := range grid {
 		for _, profile := range p {
 			if profile[0] <= stdtemp {
 				lower = profile
@@ -5,6 +5,7 @@
 package driver
 
 import (
+	"errors"
 	"image/color"
 	"testing"
 
@@ -41,7 +42,7 @@ func TestPaletteFromColorsInvalid(t *testing.T) {
 	palette, err := paletteFromColors([]string{"foobar"})
 
 	assert.Nil(t, palette)
-	assert.Error(t, err)
+	assert.True(t, errors.Is(err, ErrInvalidColor))
 }
 
 func TestMakeRange(t *testing.T) {
@@ -52,9 +53,81 @@ func TestMakeRange(t *testing.T) {
 }
 
 func TestParseProfile(t *testing.T) {
-	profile := parseProfile("20 25  35 25  50 55  60 100")
+	profile, err := parseProfile("20 25  35 25  50 55  60 100")
 
 	assert.Equal(t, SpeedProfile{{20, 25}, {35, 25}, {50, 55}, {60, 100}}, profile)
+	assert.Nil(t, err)
+}
+
+var parseProfileSyntaxTests = []string{
+	"20 25 35 25 50 55 60 100",
+	"20 25\n35 25\n50 55\n60 100",
+	"20:25,35:25,50:55,60:100",
+	"20:25, 35:25, 50:55, 60:100",
+	"20 : 25  35: 25  50 :55  60:100",
+	"20°C:25% 35°C:25% 50C:55% 60c:100%",
+	"20℃ 25%  35C 25%  50 55  60 100",
+	"68F:25 95F:25 122°F:55 140F:100",
+	"20:25 35 25, 50:55 60 100",
+}
+
+func TestParseProfileSyntaxes(t *testing.T) {
+	for _, in := range parseProfileSyntaxTests {
+		t.Run(in, func(t *testing.T) {
+			profile, err := parseProfile(in)
+
+			assert.Nil(t, err)
+			assert.Equal(t, SpeedProfile{{20, 25}, {35, 25}, {50, 55}, {60, 100}}, profile)
+		})
+	}
+}
+
+var parseProfileInvalidTests = []struct {
+	in  string
+	err string
+}{
+	{"", "please provide temperature & duty pairs"},
+	{"20", "temperature 20 has no duty"},
+	{"20 25  35", "odd number of values (3), temperature 35 has no duty"},
+	{"twenty 25", `temperature "twenty" is not a number`},
+	{"20 25  35 fast", `duty "fast" is not a number`},
+	{"20:25:30", `"20:25:30" is not a temperature:duty pair`},
+	{"20:,35:25", `"20:" is not a temperature:duty pair`},
+	{"35 20:25", "temperature 35 has no duty before 20:25"},
+	{"25% 20", `"25%" is a duty where a temperature is expected`},
+	{"20 25°C", `"25°C" is a temperature where a duty is expected`},
+	{"20K 25", `temperature "20K" has an unknown unit "K"`},
+	{"20 25rpm", `duty "25rpm" has an unknown unit "rpm"`},
+	{"20.5 25", `temperature "20.5" is not a whole number of °C`},
+	{"20 25.5", `duty "25.5" is not a whole number of %`},
+}
+
+func TestParseProfileInvalid(t *testing.T) {
+	for _, tt := range parseProfileInvalidTests {
+		t.Run(tt.in, func(t *testing.T) {
+			profile, err := parseProfile(tt.in)
+
+			assert.Nil(t, profile)
+			assert.True(t, errors.Is(err, ErrInvalidProfile))
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestGenerateStepsNotEnoughColors(t *testing.T) {
+	steps, err := generateSteps(color.Palette{}, 2, 8, "fading", 0)
+
+	assert.Nil(t, steps)
+	assert.True(t, errors.Is(err, ErrNotEnoughColors))
+}
+
+func TestGenerateStepsSuperWave(t *testing.T) {
+	red, green := &color.RGBA{255, 0, 0, 1}, &color.RGBA{0, 255, 0, 1}
+
+	steps, err := generateSteps(color.Palette{red, green}, 1, 8, "super-wave", 1)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []color.Palette{{color.RGBA{A: 1}, red, green}}, steps)
 }
 
 var normalizeTests = []struct {
@@ -69,18 +142,19 @@ var normalizeTests = []struct {
 func TestNormalizeProfile(t *testing.T) {
 	for _, tnt := range normalizeTests {
 		t.Run(tnt.in, func(t *testing.T) {
-			profile := parseProfile(tnt.in)
+			profile, _ := parseProfile(tnt.in)
 			assert.Equal(t, tnt.out, normalizeProfile(profile, criticalTemp))
 		})
 	}
 }
 
 func TestInterpolateProfile(t *testing.T) {
-	profile := parseProfile("20 25  35 25  50 55  60 100")
-	assert.Equal(t, SpeedProfile{{20, 25}, {22, 25}, {24, 25}, {26, 25}, {28, 25}, {30, 25}, {32, 25}, {34, 25}, {36, 27}, {38, 31}, {40, 35}, {42, 39}, {44, 43}, {46, 47}, {48, 51}, {50, 55}, {52, 64}, {54, 73}, {56, 82}, {58, 91}, {60, 100}}, interpolateProfile(profile))
+	profile, _ := parseProfile("20 25  35 25  50 55  60 100")
+	assert.Equal(t, SpeedProfile{{20, 25}, {22, 25}, {24, 25}, {26, 25}, {28, 25}, {30, 25}, {32, 25}, {34, 25}, {36, 27}, {38, 31}, {40, 35}, {42, 39}, {44, 43}, {46, 47}, {48, 51}, {50, 55}, {52, 64}, {54, 73}, {56, 82}, {58, 91}, {60, 100}}, interpolateProfile(profile, DefaultCurve.Grid()))
 }
 
 func TestNormalizeInterpolateProfile(t *testing.T) {
-	profile := normalizeProfile(parseProfile("20 25  35 25  50 55  60 100"), criticalTemp)
-	assert.Equal(t, SpeedProfile{{20, 25}, {22, 25}, {24, 25}, {26, 25}, {28, 25}, {30, 25}, {32, 25}, {34, 25}, {36, 27}, {38, 31}, {40, 35}, {42, 39}, {44, 43}, {46, 47}, {48, 51}, {50, 55}, {52, 64}, {54, 73}, {56, 82}, {58, 91}, {60, 100}}, interpolateProfile(profile))
+	parsed, _ := parseProfile("20 25  35 25  50 55  60 100")
+	profile := normalizeProfile(parsed, criticalTemp)
+	assert.Equal(t, SpeedProfile{{20, 25}, {22, 25}, {24, 25}, {26, 25}, {28, 25}, {30, 25}, {32, 25}, {34, 25}, {36, 27}, {38, 31}, {40, 35}, {42, 39}, {44, 43}, {46, 47}, {48, 51}, {50, 55}, {52, 64}, {54, 73}, {56, 82}, {58, 91}, {60, 100}}, interpolateProfile(profile, DefaultCurve.Grid()))
 }
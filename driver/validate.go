@@ -0,0 +1,104 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ProfileIssue is something in a speed profile the device can't follow as given
+type ProfileIssue struct {
+	Point  []int // temperature & duty, nil if the issue is about the whole profile
+	Reason string
+}
+
+// String returns the issue prefixed with its point, e.g. point 70 100: temperature outside the supported range 20-60 °C
+func (i ProfileIssue) String() string {
+	if i.Point == nil {
+		return i.Reason
+	}
+
+	return fmt.Sprintf("point %s: %s", formatPoint(i.Point), i.Reason)
+}
+
+// ValidateProfile lists everything about `p` that is silently clamped, dropped or rewritten when setting it on `channel`
+func (c CurveConfig) ValidateProfile(channel string, p SpeedProfile) []ProfileIssue {
+	if len(p) == 0 {
+		return []ProfileIssue{{Reason: "no points"}}
+	}
+
+	// unknown channels are rejected when setting the profile, there is just no minimum duty to check against
+	var issues []ProfileIssue
+	dmin := 0
+	if speedChannel, ok := speedChannels[channel]; ok {
+		dmin = speedChannel[1]
+	}
+
+	for _, point := range p {
+		if len(point) != 2 {
+			return append(issues, ProfileIssue{Point: point, Reason: "not a temperature & duty pair"})
+		}
+
+		temp, duty := point[0], point[1]
+		if temp < c.GridStart || temp > c.CriticalTemp {
+			issues = append(issues, ProfileIssue{point, fmt.Sprintf("temperature outside the supported range %d-%d °C", c.GridStart, c.CriticalTemp)})
+		}
+
+		if duty < 0 || duty > 100 {
+			issues = append(issues, ProfileIssue{point, "duty outside 0-100 %"})
+		} else if duty < dmin {
+			issues = append(issues, ProfileIssue{point, fmt.Sprintf("duty below the %s minimum of %d %%, it runs at %d %%", channel, dmin, dmin)})
+		}
+	}
+
+	sorted := append(SpeedProfile{}, p...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i][0] < sorted[j][0]
+	})
+
+	highest := sorted[0]
+	for i, point := range sorted[1:] {
+		if point[0] == sorted[i][0] {
+			issues = append(issues, ProfileIssue{point, fmt.Sprintf("duplicate temperature, also in point %s", formatPoint(sorted[i]))})
+		} else if point[1] < highest[1] {
+			issues = append(issues, ProfileIssue{point, fmt.Sprintf("duty decreases from %d %% at %d °C", highest[1], highest[0])})
+		}
+
+		if point[1] >= highest[1] {
+			highest = point
+		}
+	}
+
+	// normalizeProfile replaces these with a point at the critical temperature
+	if last := sorted[len(sorted)-1]; last[0] < c.CriticalTemp && last[1] == 100 {
+		issues = append(issues, ProfileIssue{last, fmt.Sprintf("moved to %d 100, full speed is only reached at the critical temperature", c.CriticalTemp)})
+	} else if last[0] == c.CriticalTemp && last[1] != 100 {
+		issues = append(issues, ProfileIssue{last, fmt.Sprintf("replaced by %d 100, the device always runs at full speed from the critical temperature", c.CriticalTemp)})
+	}
+
+	return issues
+}
+
+// checkProfile returns an error listing all issues of `p`, if any
+func (c CurveConfig) checkProfile(channel string, p SpeedProfile) error {
+	issues := c.ValidateProfile(channel, p)
+	if len(issues) == 0 {
+		return nil
+	}
+
+	var reasons []string
+	for _, issue := range issues {
+		reasons = append(reasons, issue.String())
+	}
+
+	return fmt.Errorf("%w for channel %s: %s", ErrInvalidProfile, channel, strings.Join(reasons, "; "))
+}
+
+// formatPoint formats a profile point as given on the command line, e.g. 35 25
+func formatPoint(point []int) string {
+	return strings.Trim(fmt.Sprint(point), "[]")
+}
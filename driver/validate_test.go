@@ -0,0 +1,71 @@
+// coolctl – A cross-platform driver for NZXT Kraken X (X42, X52, X62 or X72).
+// Copyright (c) 2019 Arkadius Stefanski
+
+// Package driver contains all code for controlling devices
+package driver
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var validateProfileTests = []struct {
+	channel string
+	profile SpeedProfile
+	issues  []string
+}{
+	{"fan", SpeedProfile{{20, 25}, {35, 25}, {50, 55}, {60, 100}}, nil},
+	{"pump", SpeedProfile{{20, 60}, {35, 60}, {55, 100}, {60, 100}}, nil},
+	{"fan", SpeedProfile{{50, 55}, {20, 25}}, nil},
+	{"fan", SpeedProfile{}, []string{"no points"}},
+	{"fan", SpeedProfile{{10, 25}, {60, 100}}, []string{"point 10 25: temperature outside the supported range 20-60 °C"}},
+	{"fan", SpeedProfile{{20, 25}, {70, 100}}, []string{"point 70 100: temperature outside the supported range 20-60 °C"}},
+	{"fan", SpeedProfile{{20, 25}, {60, 120}}, []string{
+		"point 60 120: duty outside 0-100 %",
+		"point 60 120: replaced by 60 100, the device always runs at full speed from the critical temperature",
+	}},
+	{"fan", SpeedProfile{{20, 10}, {60, 100}}, []string{"point 20 10: duty below the fan minimum of 25 %, it runs at 25 %"}},
+	{"pump", SpeedProfile{{20, 40}, {60, 100}}, []string{"point 20 40: duty below the pump minimum of 50 %, it runs at 50 %"}},
+	{"fan", SpeedProfile{{20, 25}, {40, 50}, {40, 60}, {60, 100}}, []string{"point 40 60: duplicate temperature, also in point 40 50"}},
+	{"fan", SpeedProfile{{20, 25}, {40, 60}, {50, 40}, {60, 100}}, []string{"point 50 40: duty decreases from 60 % at 40 °C"}},
+	{"fan", SpeedProfile{{20, 25}, {50, 100}}, []string{"point 50 100: moved to 60 100, full speed is only reached at the critical temperature"}},
+	{"fan", SpeedProfile{{20, 25}, {60, 80}}, []string{"point 60 80: replaced by 60 100, the device always runs at full speed from the critical temperature"}},
+	{"fan", SpeedProfile{{20, 25}, {35}}, []string{"point 35: not a temperature & duty pair"}},
+}
+
+func TestValidateProfile(t *testing.T) {
+	for _, tt := range validateProfileTests {
+		var issues []string
+		for _, issue := range DefaultCurve.ValidateProfile(tt.channel, tt.profile) {
+			issues = append(issues, issue.String())
+		}
+
+		assert.Equal(t, tt.issues, issues, "%s %v", tt.channel, tt.profile)
+	}
+}
+
+func TestSetSpeedStrict(t *testing.T) {
+	transport := NewMemoryTransport()
+	kraken := NewKrakenDriverWithTransport(transport)
+	kraken.StrictProfiles = true
+
+	err := kraken.SetSpeed("pump", "20 40  35 60  60 100")
+
+	assert.True(t, errors.Is(err, ErrInvalidProfile))
+	assert.Contains(t, err.Error(), "point 20 40: duty below the pump minimum of 50 %")
+	assert.Empty(t, transport.Writes)
+
+	require.Nil(t, kraken.SetSpeed("pump", "20 60  35 60  60 100"))
+	assert.Len(t, transport.Writes, 21)
+}
+
+func TestSetFixedSpeedIgnoresStrict(t *testing.T) {
+	transport := NewMemoryTransport(statusReport())
+	kraken := NewKrakenDriverWithTransport(transport)
+	kraken.StrictProfiles = true
+
+	assert.Nil(t, kraken.SetFixedSpeed("fan", "40"))
+}
@@ -3,14 +3,15 @@ module github.com/arkste/coolctl
 go 1.13
 
 require (
+	github.com/BurntSushi/toml v0.3.1
 	github.com/google/gousb v0.0.0-20190812193832-18f4c1d8a750
 	github.com/konsorten/go-windows-terminal-sequences v1.0.2 // indirect
 	github.com/kr/pretty v0.1.0 // indirect
 	github.com/sirupsen/logrus v1.4.2
 	github.com/spf13/cobra v0.0.5
-	github.com/spf13/pflag v1.0.5 // indirect
+	github.com/spf13/pflag v1.0.5
 	github.com/stretchr/testify v1.4.0
 	golang.org/x/sys v0.0.0-20191115151921-52ab43148777 // indirect
 	gopkg.in/check.v1 v1.0.0-20180628173108-788fd7840127 // indirect
-	gopkg.in/yaml.v2 v2.2.5 // indirect
+	gopkg.in/yaml.v2 v2.2.5
 )